@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+)
+
+// Grupo de comandos mostrado pela ajuda
+type GrupoComandos struct {
+	Nome     string
+	Comandos string
+	Pareado  bool //Comandos que só valem com o jogador pareado
+}
+
+// Comandos do menu agrupados por assunto, na ordem em que a ajuda mostra
+var gruposComandos = []GrupoComandos{
+	{"Grupo", "Parear <id>", false},
+	{"Grupo", "Parceiro <id> / Sair_Grupo / Mensagem <texto>", true},
+	{"Batalhas", "IA [Facil|Medio|Dificil] [regras] / Campanha / Missao <n>", false},
+	{"Batalhas", "Batalhar [regras] / Draft [regras] / Selado [regras] / Serie <3|5> [regras] / Equipes <companheiro> [regras] / Todos [regras]", true},
+	{"Coleção", "Abrir [pacote] / Saldo / Deck / Decks / Selecionar <deck> / Catalogo / Procedencia <carta>", false},
+	{"Evolução", "Fundir <cartas> / Desmontar <cartas> / Fabricar <modelo>", false},
+	{"Trocas", "Colecao [id] / Trocar <suas> por <dele> / Trocas / Aceitar <id> / Contrapropor <id> <suas> por <dele> / Cancelar <id>", true},
+	{"Mercado", "Anuncios / Anunciar <carta> <preço> / Leiloar <carta> <lance> <segundos> / Comprar <id> / Lance <id> <valor> / Retirar <id>", false},
+	{"Torneios", "Torneios / Torneio <formato> [regras] / Inscrever <id> / Iniciar <id>", false},
+	{"Progresso", "Missoes / Conquistas / Regras / Ranking [n] / Temporada [n] / Historico [n] / Estatisticas [id]", false},
+	{"Partidas", "Batalhas / Assistir <id> / Replay <id> [velocidade]", false},
+	{"Chat", "Lobby <texto> / Sussurrar <id|apelido> <texto> / Apelido <nome> / Salas / Entrar <sala> / Sala <sala> <texto> / Sair_Sala <sala>", false},
+	{"Moderação", "Bloquear <id|apelido> / Desbloquear <id|apelido> / Bloqueados / Admin <senha> / Silenciar <id|apelido> <duração>", false},
+	{"Amigos", "Amigos / Amizade <id|apelido> / Aceitar_Amigo <id> / Recusar_Amigo <id> / Remover_Amigo <id>", false},
+	{"Outros", "Latencia / Ajuda / Sair", false},
+}
+
+// Função para imprimir os comandos disponíveis no estado do jogador, um grupo por linha
+func imprimirAjuda(pareado bool) {
+	color.Cyan("Comandos disponíveis:")
+	for _, grupo := range gruposComandos {
+		if grupo.Pareado && !pareado {
+			continue
+		}
+		fmt.Printf("  %-10s %s\n", grupo.Nome+":", grupo.Comandos)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Amigo ou pedido de amizade recebido do servidor, com a presença atual
+type Amigo struct {
+	Id       string `json:"id"`
+	Apelido  string `json:"apelido"`
+	Presenca string `json:"presenca"`
+	Pendente bool   `json:"pendente"`
+}
+
+// Função para tratar os comandos de amizade, retorna false se não for um deles
+func tratarComandoAmigos(conn net.Conn, line string) bool {
+	if line == "Amigos" {
+		enviarRequisicao(conn, Requisicao{Tipo: "Amigos", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+	} else if strings.HasPrefix(line, "Amizade ") {
+		//Id ou apelido do jogador, ex: "Amizade Ana"
+		enviarRequisicao(conn, Requisicao{Tipo: "Pedir_Amizade", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Amizade ")})
+	} else if strings.HasPrefix(line, "Aceitar_Amigo ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Aceitar_Amizade", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Aceitar_Amigo ")})
+	} else if strings.HasPrefix(line, "Recusar_Amigo ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Recusar_Amizade", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Recusar_Amigo ")})
+	} else if strings.HasPrefix(line, "Remover_Amigo ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Remover_Amigo", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Remover_Amigo ")})
+	} else {
+		return false
+	}
+	return true
+}
+
+// Função para imprimir os amigos com a presença e os pedidos de amizade recebidos
+func imprimirAmigos(lista []Amigo) {
+	if len(lista) == 0 {
+		color.Yellow("Você ainda não tem amigos nem pedidos de amizade")
+		return
+	}
+	color.Cyan("Amigos:")
+	for _, a := range lista {
+		nome := a.Id
+		if a.Apelido != "" {
+			nome = fmt.Sprintf("%s (%s)", a.Apelido, a.Id)
+		}
+		if a.Pendente {
+			color.Yellow("  %s quer ser seu amigo (%s)", nome, a.Presenca)
+		} else if a.Presenca == "Offline" {
+			fmt.Printf("  %s: %s\n", nome, a.Presenca)
+		} else {
+			color.Green("  %s: %s", nome, a.Presenca)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+)
+
+// Situação de uma missão da campanha recebida do servidor
+type EtapaCampanha struct {
+	Numero     int    `json:"numero"`
+	Nome       string `json:"nome"`
+	Descricao  string `json:"descricao"`
+	Especiais  string `json:"especiais"`
+	Recompensa string `json:"recompensa"`
+	Liberada   bool   `json:"liberada"`
+	Concluida  bool   `json:"concluida"`
+}
+
+// Função para imprimir as missões da campanha, destacando as concluídas e escondendo as bloqueadas
+func imprimirCampanha(etapas []EtapaCampanha) {
+	if len(etapas) == 0 {
+		color.Yellow("Nenhuma missão de campanha disponível")
+		return
+	}
+	for _, e := range etapas {
+		if !e.Liberada {
+			fmt.Printf("  %d. ??? (bloqueada)\n", e.Numero)
+			continue
+		}
+		linha := fmt.Sprintf("  %d. %s: %s\n     Regras especiais: %s. Recompensa: %s", e.Numero, e.Nome, e.Descricao, e.Especiais, e.Recompensa)
+		if e.Concluida {
+			color.Green(linha + " (concluída)")
+		} else {
+			fmt.Println(linha)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Experiência para subir cada nível, igual à do servidor
+const ExperienciaNivel = 100
+
+// Registro de uma mudança de dono da carta recebido do servidor
+type RegistroPosse struct {
+	Dono   string    `json:"dono"`
+	Origem string    `json:"origem"`
+	Data   time.Time `json:"data"`
+}
+
+// Função para montar o nome da carta com o número de série, quando ela tem um
+func nomeCarta(t Tanque) string {
+	if t.Serie == 0 {
+		return t.Modelo
+	}
+	return fmt.Sprintf("%s #%d", t.Modelo, t.Serie)
+}
+
+// Função para imprimir o histórico de donos de uma carta
+func imprimirProcedencia(instancia string, historico []RegistroPosse) {
+	color.Cyan("Procedência da carta %s:", instancia)
+	for i, registro := range historico {
+		fmt.Printf("  %d. %s - jogador %s (%s)\n", i+1, registro.Data.Format("02/01 15:04:05"), registro.Dono, registro.Origem)
+	}
+}
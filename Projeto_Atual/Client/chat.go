@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Mensagem de chat recebida do servidor
+type MensagemChat struct {
+	Canal        string    `json:"canal"`
+	Remetente    string    `json:"remetente"`
+	Apelido      string    `json:"apelido"`
+	Destinatario string    `json:"destinatario"`
+	Texto        string    `json:"texto"`
+	Horario      time.Time `json:"horario"`
+}
+
+// Resumo de uma sala de chat recebido do servidor
+type ResumoSala struct {
+	Nome    string   `json:"nome"`
+	Membros []string `json:"membros"`
+}
+
+// Função para tratar os comandos de chat, retorna false se não for um deles
+func tratarComandoChat(conn net.Conn, line string) bool {
+	if strings.HasPrefix(line, "Lobby ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Lobby", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Lobby ")})
+	} else if strings.HasPrefix(line, "Sussurrar ") {
+		//Id ou apelido do jogador e o texto, ex: "Sussurrar Ana oi"
+		alvo, texto, ok := strings.Cut(strings.TrimPrefix(line, "Sussurrar "), " ")
+		if !ok {
+			color.Red("Use Sussurrar <id|apelido> <texto>")
+			return true
+		}
+		enviarRequisicao(conn, Requisicao{Tipo: "Sussurro", Id_remetente: idPessoal, Id_destinatario: alvo, Mensagem: texto})
+	} else if strings.HasPrefix(line, "Apelido ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Apelido", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Apelido ")})
+	} else if line == "Salas" {
+		enviarRequisicao(conn, Requisicao{Tipo: "Listar_Salas", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+	} else if strings.HasPrefix(line, "Entrar ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Entrar_Sala", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Entrar ")})
+	} else if strings.HasPrefix(line, "Sair_Sala ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Sair_Sala", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Sair_Sala ")})
+	} else if strings.HasPrefix(line, "Sala ") {
+		//Nome da sala e o texto, ex: "Sala veteranos boa partida"
+		sala, texto, ok := strings.Cut(strings.TrimPrefix(line, "Sala "), " ")
+		if !ok {
+			color.Red("Use Sala <nome> <texto>")
+			return true
+		}
+		enviarRequisicao(conn, Requisicao{Tipo: "Mensagem_Sala", Id_remetente: idPessoal, Id_destinatario: sala, Mensagem: texto})
+	} else if strings.HasPrefix(line, "Bloquear ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Bloquear", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Bloquear ")})
+	} else if strings.HasPrefix(line, "Desbloquear ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Desbloquear", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Desbloquear ")})
+	} else if line == "Bloqueados" {
+		enviarRequisicao(conn, Requisicao{Tipo: "Bloqueados", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+	} else if strings.HasPrefix(line, "Admin ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Admin", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Admin ")})
+	} else if strings.HasPrefix(line, "Silenciar ") {
+		//Id ou apelido e a duração, ex: "Silenciar 3 10m", duração 0 retira o silêncio
+		enviarRequisicao(conn, Requisicao{Tipo: "Silenciar", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Silenciar ")})
+	} else {
+		return false
+	}
+	return true
+}
+
+// Função para imprimir uma mensagem de chat com horário, canal e remetente
+func imprimirChat(chat MensagemChat) {
+	remetente := chat.Remetente
+	if chat.Apelido != "" {
+		remetente = fmt.Sprintf("%s (%s)", chat.Apelido, chat.Remetente)
+	}
+	canal := chat.Canal
+	if chat.Canal == "Sussurro" && chat.Remetente == idPessoal {
+		canal = "Sussurro para " + chat.Destinatario
+	}
+
+	linha := fmt.Sprintf("[%s] [%s] %s: %s", chat.Horario.Local().Format("15:04:05"), canal, remetente, chat.Texto)
+	if chat.Canal == "Sussurro" {
+		color.Magenta(linha)
+	} else {
+		color.Cyan(linha)
+	}
+}
+
+// Função para imprimir as salas de chat existentes
+func imprimirSalas(lista []ResumoSala) {
+	if len(lista) == 0 {
+		color.Yellow("Nenhuma sala de chat aberta")
+		return
+	}
+	color.Cyan("Salas de chat:")
+	for _, s := range lista {
+		fmt.Printf("  %s: %d membro(s): %s\n", s.Nome, len(s.Membros), strings.Join(s.Membros, ", "))
+	}
+}
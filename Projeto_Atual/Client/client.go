@@ -3,12 +3,13 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"math/rand"
 	"net"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fatih/color"
@@ -16,26 +17,51 @@ import (
 
 // Struct como modelo de requisição do cliente para servidor
 type Requisicao struct {
-	Tipo            string `json:"tipo"`
-	Id_remetente    string `json:"id_remetente"`
-	Id_destinatario string `json:"id_destinatario"`
-	Mensagem        string `json:"mensagem"`
-	Carta           Tanque `json:"carta"`
+	Tipo            string   `json:"tipo"`
+	Id_remetente    string   `json:"id_remetente"`
+	Id_destinatario string   `json:"id_destinatario"`
+	Mensagem        string   `json:"mensagem"`
+	Carta           Tanque   `json:"carta"`
+	Cartas          []Tanque `json:"cartas"`
+	Pedidas         []Tanque `json:"pedidas"`
 }
 
 // Struct modelo de resposta do servidor para cliente
 type Resposta struct {
-	Tipo     string   `json:"tipo"`
-	Mensagem string   `json:"mensagem"`
-	Cartas   []Tanque `json:"cartas"`
+	Tipo         string            `json:"tipo"`
+	Mensagem     string            `json:"mensagem"`
+	Cartas       []Tanque          `json:"cartas"`
+	Decks        []Deck            `json:"decks"`
+	Ranking      []PosicaoRanking  `json:"ranking"`
+	Partidas     []Partida         `json:"partidas"`
+	Estatisticas *Estatisticas     `json:"estatisticas"`
+	Replay       *Replay           `json:"replay"`
+	Evento       *EventoReplay     `json:"evento"`
+	Batalhas     []ResumoBatalha   `json:"batalhas"`
+	Trocas       []Troca           `json:"trocas"`
+	Anuncios     []Anuncio         `json:"anuncios"`
+	Procedencia  []RegistroPosse   `json:"procedencia"`
+	Missoes      []ProgressoMissao `json:"missoes"`
+	Temporada    *Temporada        `json:"temporada"`
+	Torneios     []ResumoTorneio   `json:"torneios"`
+	Campanha     []EtapaCampanha   `json:"campanha"`
+	Chat         *MensagemChat     `json:"chat"`
+	Salas        []ResumoSala      `json:"salas"`
+	Amigos       []Amigo           `json:"amigos"`
+	Codigo       string            `json:"codigo"`
 }
 
 // Carta do jogo
 type Tanque struct {
-	Modelo     string `json:"modelo"`
-	Id_jogador string `json:"id_jogador"`
-	Vida       int    `json:"vida"`
-	Ataque     int    `json:"ataque"`
+	Modelo      string `json:"modelo"`
+	Id_jogador  string `json:"id_jogador"`
+	Vida        int    `json:"vida"`
+	Ataque      int    `json:"ataque"`
+	Instancia   int    `json:"instancia"`
+	Serie       int    `json:"serie"`
+	Estrelas    int    `json:"estrelas"`
+	Nivel       int    `json:"nivel"`
+	Experiencia int    `json:"experiencia"`
 }
 
 // Struct para requisição de Ping (UDP)
@@ -50,20 +76,35 @@ const (
 	EstadoEsperandoResposta
 	EstadoBatalhando
 	EstadoMostrandoLatencia
+	EstadoEscolhendoCarta
+	EstadoAssistindo
+	EstadoEditandoDeck
 )
 
 // Variáveis para informações pertinentes ao jogador
 var idPessoal, idParceiro string //IDs próprio e de possível oponente
+var membrosGrupo []string        //IDs dos jogadores do grupo, incluindo o próprio
 var minhasCartas []Tanque        //Lista de cartas adquiridas
+var muCartas sync.Mutex          //Mutex para sincronizar minhasCartas entre a leitura do servidor e os comandos do terminal
 
 func main() {
 	color.NoColor = false
 
+	//Reprodução de um arquivo de replay sem conectar ao servidor, ex: go run . -replay replay_3.json.gz -velocidade 2
+	arquivoReplay := flag.String("replay", "", "Arquivo de replay para assistir sem conexão")
+	velocidadeReplay := flag.Float64("velocidade", 1, "Velocidade da reprodução do replay")
+	flag.Parse()
+	if *arquivoReplay != "" {
+		reproduzirArquivoReplay(*arquivoReplay, *velocidadeReplay)
+		return
+	}
+
 	//Conexão do tipo TCP com o servidor
-	conn, err := net.Dial("tcp", "server:8080")
+	original, err := net.Dial("tcp", EnderecoServidor)
 	if err != nil {
 		panic(err)
 	}
+	conn := &Conexao{conn: original} //Trocada por uma conexão nova se a atual cair
 	defer conn.Close()
 
 	//Estado atual do jogador
@@ -73,15 +114,40 @@ func main() {
 	//Lista para guardar deck de batalha de uma possível batalha
 	deckBatalha := make([]Tanque, 0, 5)
 
+	//Cartas disponíveis e prazo para a escolha da próxima carta da batalha
+	var cartasDisponiveis []Tanque
+	var prazoEscolha time.Time
+	tipoEscolha := "Próxima_Carta"    //Requisição da escolha pedida: "Próxima_Carta" ou "Alvo"
+	pedidoCarta := make(chan bool, 1) //Avisa o loop do terminal que uma carta foi pedida
+
 	idPessoal = "none"
 	idParceiro = "none"
 	//Goroutine (thread) para ouvir respostas do servidor
 	go func() {
+		reader := bufio.NewReader(conn) //Leitor único para não perder respostas que chegam juntas
 		for {
-			resposta := lerResposta(conn)
+			resposta, err := lerResposta(reader)
+			if err != nil {
+				if tokenSessao == "" {
+					color.Red("Conexão com o servidor encerrada")
+					os.Exit(0)
+				}
+
+				//Tenta voltar para a mesma sessão, a resposta é "Reconectado" ou "Nova_Sessao"
+				reader, resposta, err = reconectarServidor(conn)
+				if err != nil {
+					color.Red("Erro: %v", err)
+					os.Exit(1)
+				}
+			}
+
 			switch resposta.Tipo {
 			case "Erro":
-				color.Red("Erro: %s", resposta.Mensagem)
+				if resposta.Codigo != "" {
+					color.Red("Erro [%s]: %s", resposta.Codigo, resposta.Mensagem)
+				} else {
+					color.Red("Erro: %s", resposta.Mensagem)
+				}
 				if idParceiro == "none" {
 					estadoAtual = EstadoLivre
 				} else {
@@ -92,38 +158,294 @@ func main() {
 				color.Yellow("Parece que seu jogador pareado desconectou :(")
 				estadoAtual = EstadoLivre
 				idParceiro = "none"
+				membrosGrupo = nil
+
+			case "Grupo_Desfeito":
+				color.Yellow(resposta.Mensagem)
+				estadoAtual = EstadoLivre
+				idParceiro = "none"
+				membrosGrupo = nil
+
+			case "Grupo_Atualizado":
+				membrosGrupo = strings.Split(resposta.Mensagem, ",")
+				color.Green("Seu grupo: %s", strings.Join(membrosGrupo, ", "))
+
+				//Escolhe outro parceiro se o atual saiu do grupo
+				if !contem(membrosGrupo, idParceiro) {
+					for _, membro := range membrosGrupo {
+						if membro != idPessoal {
+							idParceiro = membro
+							break
+						}
+					}
+				}
+				if estadoAtual == EstadoLivre || estadoAtual == EstadoEsperandoResposta {
+					estadoAtual = EstadoPareado
+				}
 
 			case "Criaçao_Id":
 				color.Yellow("Seu ID é %s", resposta.Mensagem)
 				idPessoal = resposta.Mensagem
 				estadoAtual = EstadoLivre
 
+			case "Sessao":
+				tokenSessao = resposta.Mensagem
+
+			case "Reconectado":
+				color.Green("Reconectado com o ID %s", resposta.Mensagem)
+				idPessoal = resposta.Mensagem
+				if estadoAtual == EstadoEsperandoResposta {
+					if idParceiro == "none" {
+						estadoAtual = EstadoLivre
+					} else {
+						estadoAtual = EstadoPareado
+					}
+				}
+
+			case "Nova_Sessao":
+				color.Yellow("Sessão anterior expirou, seu novo ID é %s", resposta.Mensagem)
+				idPessoal = resposta.Mensagem
+				idParceiro = "none"
+				membrosGrupo = nil
+				estadoAtual = EstadoLivre
+				select {
+				case pedidoCarta <- true:
+				default:
+				}
+
+			case "Jogador_Ausente", "Batalha_Pausada":
+				color.Yellow(resposta.Mensagem)
+
+			case "Jogador_Reconectado":
+				color.Green(resposta.Mensagem)
+
+			case "Batalha_Retomada":
+				color.Green(resposta.Mensagem)
+				if estadoAtual == EstadoLivre || estadoAtual == EstadoPareado || estadoAtual == EstadoEsperandoResposta {
+					estadoAtual = EstadoBatalhando
+					select {
+					case pedidoCarta <- true:
+					default:
+					}
+				}
+
 			case "Pareamento":
 				color.Green("Pareamento realizado com %s", resposta.Mensagem)
-				idParceiro = resposta.Mensagem
-				estadoAtual = EstadoPareado
+				if idParceiro == "none" {
+					idParceiro = resposta.Mensagem
+				}
+				if estadoAtual == EstadoLivre || estadoAtual == EstadoEsperandoResposta {
+					estadoAtual = EstadoPareado
+				}
+
+			case "Mensagem", "Chat":
+				if resposta.Chat != nil {
+					imprimirChat(*resposta.Chat)
+				} else {
+					color.Cyan("Mensagem recebida: %s", resposta.Mensagem)
+				}
 
-			case "Mensagem":
-				color.Cyan("Mensagem recebida: %s", resposta.Mensagem)
+			case "Apelido":
+				color.Green("Seu apelido agora é %s", resposta.Mensagem)
+
+			case "Sala":
+				color.Cyan(resposta.Mensagem)
+
+			case "Lista_Salas":
+				imprimirSalas(resposta.Salas)
+
+			case "Moderacao":
+				color.Magenta(resposta.Mensagem)
+
+			case "Amizade", "Pedido_Amizade", "Presenca":
+				color.Cyan(resposta.Mensagem)
+
+			case "Lista_Amigos":
+				imprimirAmigos(resposta.Amigos)
 
 			case "Sorteio":
-				minhasCartas = append(minhasCartas, resposta.Cartas...)
+				adicionarCartas(resposta.Cartas)
 
 				color.Green("%s\n", resposta.Mensagem)
 				imprimirTanques(resposta.Cartas)
 
-			case "Inicio_Batalha":
-				color.Yellow("Batalha iniciada com %s", resposta.Mensagem)
-				deckBatalha = nil
-				if len(minhasCartas) > 0 {
-					deckBatalha = append(deckBatalha, sortearDeck()...)
+			case "Saldo":
+				color.Yellow(resposta.Mensagem)
+
+			case "Mercado":
+				//Eventos do mercado que mudam a coleção trazem a coleção atualizada
+				if resposta.Cartas != nil {
+					trocarCartas(resposta.Cartas)
+				}
+				color.Yellow(resposta.Mensagem)
+
+			case "Fusao", "Evolucao", "Sucata":
+				trocarCartas(resposta.Cartas)
+				color.Green(resposta.Mensagem)
+
+			case "Catalogo":
+				color.Cyan("Catálogo de tanques:")
+				imprimirResumoTanques(resposta.Cartas)
+				color.Yellow(resposta.Mensagem)
+
+			case "Missoes":
+				imprimirMissoes(resposta.Mensagem, resposta.Missoes)
+
+			case "Conquistas":
+				imprimirMissoes("Conquistas", resposta.Missoes)
+
+			case "Missao_Concluida":
+				adicionarCartas(resposta.Cartas)
+				color.Green(resposta.Mensagem)
+				imprimirResumoTanques(resposta.Cartas)
+
+			case "Campanha":
+				color.Cyan(resposta.Mensagem)
+				imprimirCampanha(resposta.Campanha)
+
+			case "Missao_Campanha":
+				adicionarCartas(resposta.Cartas)
+				color.Green(resposta.Mensagem)
+				imprimirResumoTanques(resposta.Cartas)
+
+			case "Conquista_Desbloqueada":
+				color.Green(resposta.Mensagem)
+
+			case "Procedencia":
+				imprimirProcedencia(resposta.Mensagem, resposta.Procedencia)
+
+			case "Lista_Anuncios":
+				imprimirAnuncios(resposta.Anuncios)
+
+			case "Torneio":
+				color.Cyan(resposta.Mensagem)
+				for _, t := range resposta.Torneios {
+					imprimirClassificacao(t)
+				}
+
+			case "Lista_Torneios":
+				imprimirTorneios(resposta.Torneios)
+
+			case "Fim_Torneio":
+				color.Yellow(resposta.Mensagem)
+				for _, t := range resposta.Torneios {
+					imprimirClassificacao(t)
+				}
+
+			case "Colecao":
+				if resposta.Mensagem == idPessoal {
+					trocarCartas(resposta.Cartas)
+					color.Cyan("Sua coleção:")
 				} else {
-					//Inicializa deck de batalha com cartas inoperantes se não abriu um pacote
-					for i := 0; i < 5; i++ {
-						deckBatalha = append(deckBatalha, Tanque{Modelo: "Treinamento", Id_jogador: idPessoal, Vida: 1 + i, Ataque: 1})
-					}
+					colecaoVista = resposta.Cartas
+					idColecaoVista = resposta.Mensagem
+					color.Cyan("Coleção do jogador %s:", resposta.Mensagem)
+				}
+				imprimirResumoTanques(resposta.Cartas)
+
+			case "Troca_Proposta":
+				color.Green(resposta.Mensagem)
+				for _, t := range resposta.Trocas {
+					trocasConhecidas[t.Id] = t
+					imprimirTroca(t)
+				}
+
+			case "Lista_Trocas":
+				if len(resposta.Trocas) == 0 {
+					color.Yellow("Nenhuma troca pendente")
+				}
+				for _, t := range resposta.Trocas {
+					trocasConhecidas[t.Id] = t
+					imprimirTroca(t)
+				}
+
+			case "Troca_Concluida":
+				color.Green(resposta.Mensagem)
+				trocarCartas(resposta.Cartas)
+				color.Cyan("Sua coleção agora tem %d cartas", len(resposta.Cartas))
+
+			case "Troca_Cancelada":
+				color.Yellow(resposta.Mensagem)
+
+			case "Deck_Criado":
+				color.Green("Deck %s salvo", resposta.Mensagem)
+				imprimirResumoTanques(resposta.Cartas)
+				limparDeckEmEdicao()
+				if idParceiro == "none" {
+					estadoAtual = EstadoLivre
+				} else {
+					estadoAtual = EstadoPareado
 				}
 
+			case "Lista_Decks":
+				color.Cyan("Seus decks:")
+				imprimirDecks(resposta.Decks, resposta.Mensagem)
+
+			case "Deck_Selecionado":
+				color.Green("Deck %s selecionado para as próximas batalhas", resposta.Mensagem)
+
+			case "Lista_Regras":
+				color.Cyan("Regras de batalha disponíveis:")
+				fmt.Println(resposta.Mensagem)
+
+			case "Regras_Batalha":
+				color.Cyan("Regras da batalha: %s", resposta.Mensagem)
+
+			case "Ranking":
+				color.Cyan("Ranking:")
+				imprimirRanking(resposta.Ranking)
+				color.Yellow(resposta.Mensagem)
+				imprimirTemporada()
+
+			case "Temporada":
+				temporadaAtual = resposta.Temporada
+				color.Cyan(resposta.Mensagem)
+
+			case "Ranking_Temporada":
+				color.Cyan(resposta.Mensagem)
+				imprimirRanking(resposta.Ranking)
+
+			case "Recompensa_Temporada":
+				adicionarCartas(resposta.Cartas)
+				color.Green(resposta.Mensagem)
+
+			case "Historico":
+				imprimirHistorico(resposta.Partidas, resposta.Mensagem)
+
+			case "Estatisticas":
+				if resposta.Mensagem != "" {
+					color.Yellow(resposta.Mensagem)
+				}
+				imprimirEstatisticas(resposta.Estatisticas)
+
+			case "Replay_Inicio":
+				replayRecebido = resposta.Replay
+				color.Yellow("Reproduzindo replay em velocidade %sx", resposta.Mensagem)
+				imprimirCabecalhoReplay(replayRecebido)
+
+			case "Replay_Evento":
+				if replayRecebido != nil && resposta.Evento != nil {
+					replayRecebido.Eventos = append(replayRecebido.Eventos, *resposta.Evento)
+					imprimirEventoReplay(*resposta.Evento)
+				}
+
+			case "Replay_Fim":
+				color.Cyan("Fim do replay da partida %s", resposta.Mensagem)
+				if replayRecebido != nil {
+					salvarReplay(replayRecebido)
+					replayRecebido = nil
+				}
+
+			case "Draft", "Selado":
+				color.Cyan(resposta.Mensagem)
+				if len(resposta.Cartas) > 0 {
+					imprimirResumoTanques(resposta.Cartas)
+				}
+
+			case "Inicio_Batalha":
+				color.Yellow("Batalha iniciada com %s", resposta.Mensagem)
+				deckBatalha = resposta.Cartas
+
 				color.Cyan("Seu deck de batalha é:")
 				imprimirTanques(deckBatalha)
 				estadoAtual = EstadoBatalhando
@@ -131,33 +453,93 @@ func main() {
 			case "Fim_Batalha":
 				color.Yellow("Batalha finalizada!")
 				color.Cyan(resposta.Mensagem)
-				estadoAtual = EstadoPareado
+				if estadoAtual == EstadoAssistindo {
+					//Espectador volta ao estado em que estava antes de assistir
+					if idParceiro == "none" {
+						estadoAtual = EstadoLivre
+					} else {
+						estadoAtual = EstadoPareado
+					}
+					select {
+					case pedidoCarta <- true:
+					default:
+					}
+				} else if idParceiro == "none" {
+					//Batalha de torneio pode acontecer sem grupo
+					estadoAtual = EstadoLivre
+				} else {
+					estadoAtual = EstadoPareado
+				}
+
+			case "Batalhas_Ativas":
+				imprimirBatalhasAtivas(resposta.Batalhas)
+
+			case "Assistindo":
+				color.Green(resposta.Mensagem)
+				if len(resposta.Cartas) > 0 {
+					color.Yellow("Tanques em campo:")
+					imprimirTanques(resposta.Cartas)
+				}
+				estadoAtual = EstadoAssistindo
+
+			case "Espectadores":
+				color.Cyan(resposta.Mensagem)
+
+			case "Assistir_Encerrado":
+				color.Yellow(resposta.Mensagem)
+				if idParceiro == "none" {
+					estadoAtual = EstadoLivre
+				} else {
+					estadoAtual = EstadoPareado
+				}
+				select {
+				case pedidoCarta <- true:
+				default:
+				}
+
+			case "Placar_Serie":
+				color.Cyan(resposta.Mensagem)
+
+			case "Fim_Serie":
+				color.Yellow("Série finalizada!")
+				color.Cyan(resposta.Mensagem)
 
 			case "Enviar_Próxima_Carta":
-				indice, err := strconv.Atoi(resposta.Mensagem)
+				segundos, err := strconv.Atoi(resposta.Mensagem)
 
 				if err != nil {
 					fmt.Println("Erro ao converter:", err)
 					panic(err)
 				}
 
-				//Verificar se indice é válido
-				if indice < 0 || indice >= len(deckBatalha) {
-					color.Red("ERRO: Índice %d fora do range do deck (0-%d)", indice, len(deckBatalha)-1)
-					//Enviar carta padrão para não travar
-					enviarRequisicao(conn, Requisicao{
-						Tipo:            "Próxima_Carta",
-						Id_remetente:    idPessoal,
-						Id_destinatario: idParceiro,
-						Mensagem:        "Carta",
-						Carta:           Tanque{Modelo: "Padrão", Vida: 10, Ataque: 1}})
-				} else {
-					enviarRequisicao(conn, Requisicao{
-						Tipo:            "Próxima_Carta",
-						Id_remetente:    idPessoal,
-						Id_destinatario: idParceiro,
-						Mensagem:        "Carta",
-						Carta:           deckBatalha[indice]})
+				//Guarda as cartas que ainda podem ser escolhidas e o prazo, descontando 1s de margem para a rede
+				cartasDisponiveis = resposta.Cartas
+				prazoEscolha = time.Now().Add(time.Duration(segundos-1) * time.Second)
+				tipoEscolha = "Próxima_Carta"
+				estadoAtual = EstadoEscolhendoCarta
+
+				select {
+				case pedidoCarta <- true:
+				default:
+				}
+
+			case "Escolher_Alvo":
+				segundos, err := strconv.Atoi(resposta.Mensagem)
+
+				if err != nil {
+					fmt.Println("Erro ao converter:", err)
+					panic(err)
+				}
+
+				//Mesma tela de escolha, mas as cartas são os tanques dos oponentes que podem ser atacados
+				cartasDisponiveis = resposta.Cartas
+				prazoEscolha = time.Now().Add(time.Duration(segundos-1) * time.Second)
+				tipoEscolha = "Alvo"
+				estadoAtual = EstadoEscolhendoCarta
+
+				select {
+				case pedidoCarta <- true:
+				default:
 				}
 
 			case "Turno_Realizado":
@@ -172,18 +554,28 @@ func main() {
 		}
 	}()
 
-	//Loop infinito e centralizado que lê do terminal
-	reader := bufio.NewReader(os.Stdin)
+	//Goroutine única que lê do terminal, as linhas são consumidas pelo loop central
+	entradaTerminal := iniciarLeituraTerminal(bufio.NewReader(os.Stdin))
+
+	//Loop infinito e centralizado que trata o que foi lido do terminal
 	estadoAnterior := estadoAtual
 	for {
 		//Ver qual estado do jogador
 		switch estadoAtual {
 		case EstadoLivre:
-			fmt.Println("Comando Parear <id> / Abrir / Latencia / Sair: ")
-			line, _ := reader.ReadString('\n')
-			line = strings.TrimSpace(line)
+			fmt.Println("Comando Parear <id> / Abrir [pacote] / IA [dificuldade] / Latencia / Ajuda / Sair: ")
+
+			//Batalha de torneio pode ser iniciada enquanto espera um comando
+			var line string
+			select {
+			case line = <-entradaTerminal:
+			case <-pedidoCarta:
+				continue
+			}
 
 			if line == "Sair" {
+				//Encerra a sessão para o servidor não esperar a reconexão
+				enviarRequisicao(conn, Requisicao{Tipo: "Encerrar_Sessao", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
 				os.Exit(0)
 			}
 
@@ -192,7 +584,27 @@ func main() {
 				enviarRequisicao(conn, Requisicao{Tipo: "Parear", Id_remetente: idPessoal, Id_destinatario: idDestinatario, Mensagem: "None"})
 				estadoAtual = EstadoEsperandoResposta
 			} else if strings.HasPrefix(line, "Abrir") {
-				enviarRequisicao(conn, Requisicao{Tipo: "Abrir_Pacote", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+				//Tipo opcional do pacote, ex: "Abrir Pesado"
+				enviarRequisicao(conn, Requisicao{Tipo: "Abrir_Pacote", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimSpace(strings.TrimPrefix(line, "Abrir"))})
+			} else if line == "Deck" {
+				estadoAnterior = EstadoLivre
+				estadoAtual = EstadoEditandoDeck
+			} else if strings.HasPrefix(line, "Assistir ") {
+				idBatalha := strings.TrimPrefix(line, "Assistir ")
+				enviarRequisicao(conn, Requisicao{Tipo: "Assistir", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: idBatalha})
+				estadoAtual = EstadoEsperandoResposta
+			} else if line == "Ajuda" {
+				imprimirAjuda(false)
+			} else if tratarComandoGeral(conn, line) {
+				//Comando de consulta tratado
+			} else if tratarComandoMercado(conn, line) {
+				//Comando do mercado tratado
+			} else if tratarComandoTorneio(conn, line) {
+				//Comando de torneio tratado
+			} else if tratarComandoChat(conn, line) {
+				//Comando de chat tratado
+			} else if tratarComandoAmigos(conn, line) {
+				//Comando de amizade tratado
 			} else if strings.HasPrefix(line, "Latencia") {
 				estadoAnterior = EstadoLivre
 				estadoAtual = EstadoMostrandoLatencia
@@ -201,27 +613,105 @@ func main() {
 			}
 
 		case EstadoPareado:
-			fmt.Println("Comando Abrir / Mensagem / Batalhar / Latencia / Sair: ")
-			line, _ := reader.ReadString('\n')
-			line = strings.TrimSpace(line)
+			fmt.Println("Comando Mensagem <texto> / Batalhar [regras] / Abrir [pacote] / Latencia / Ajuda / Sair: ")
+
+			//Batalha pode ser iniciada pelo jogador pareado enquanto espera um comando
+			var line string
+			select {
+			case line = <-entradaTerminal:
+			case <-pedidoCarta:
+				continue
+			}
 
 			if line == "Sair" {
+				//Encerra a sessão para o servidor não esperar a reconexão
+				enviarRequisicao(conn, Requisicao{Tipo: "Encerrar_Sessao", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
 				os.Exit(0)
 			}
 
 			if strings.HasPrefix(line, "Abrir") {
-				enviarRequisicao(conn, Requisicao{Tipo: "Abrir_Pacote", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+				//Tipo opcional do pacote, ex: "Abrir Pesado"
+				enviarRequisicao(conn, Requisicao{Tipo: "Abrir_Pacote", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimSpace(strings.TrimPrefix(line, "Abrir"))})
+			} else if strings.HasPrefix(line, "Parear ") {
+				//Adiciona outro jogador ao grupo
+				idDestinatario := strings.TrimPrefix(line, "Parear ")
+				enviarRequisicao(conn, Requisicao{Tipo: "Parear", Id_remetente: idPessoal, Id_destinatario: idDestinatario, Mensagem: "None"})
+			} else if strings.HasPrefix(line, "Parceiro ") {
+				//Escolhe com qual membro do grupo duelar e conversar
+				id := strings.TrimPrefix(line, "Parceiro ")
+				if id == idPessoal || !contem(membrosGrupo, id) {
+					color.Red("Jogador %s não faz parte do seu grupo", id)
+				} else {
+					idParceiro = id
+					color.Green("Parceiro atual: %s", idParceiro)
+				}
+			} else if line == "Sair_Grupo" {
+				enviarRequisicao(conn, Requisicao{Tipo: "Sair_Grupo", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+				estadoAtual = EstadoEsperandoResposta
+			} else if strings.HasPrefix(line, "Equipes ") {
+				//Companheiro de equipe e regras opcionais, ex: "Equipes 3 Rapida"
+				enviarRequisicao(conn, Requisicao{Tipo: "Batalhar_Equipes", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Equipes ")})
+				estadoAtual = EstadoEsperandoResposta
+			} else if strings.HasPrefix(line, "Todos") {
+				regras := strings.TrimSpace(strings.TrimPrefix(line, "Todos"))
+				if regras == "" {
+					regras = "None"
+				}
+				enviarRequisicao(conn, Requisicao{Tipo: "Batalhar_Todos", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: regras})
+				estadoAtual = EstadoEsperandoResposta
 			} else if strings.HasPrefix(line, "Batalhar") {
-				if len(minhasCartas) < 5 {
+				if len(copiarCartas()) < 5 {
+					color.Red("Você não tem cartas suficientes para montar um deck")
+				} else {
+					//Nome opcional das regras de batalha depois do comando
+					regras := strings.TrimSpace(strings.TrimPrefix(line, "Batalhar"))
+					if regras == "" {
+						regras = "None"
+					}
+					enviarRequisicao(conn, Requisicao{Tipo: "Batalhar", Id_remetente: idPessoal, Id_destinatario: idParceiro, Mensagem: regras})
+					estadoAtual = EstadoEsperandoResposta
+				}
+			} else if strings.HasPrefix(line, "Draft") || strings.HasPrefix(line, "Selado") {
+				//Modos com cartas temporárias, não precisam da coleção. Regras opcionais, ex: "Draft Rapida"
+				tipo, regras, _ := strings.Cut(line, " ")
+				if strings.TrimSpace(regras) == "" {
+					regras = "None"
+				}
+				enviarRequisicao(conn, Requisicao{Tipo: "Batalhar_" + tipo, Id_remetente: idPessoal, Id_destinatario: idParceiro, Mensagem: strings.TrimSpace(regras)})
+				estadoAtual = EstadoEsperandoResposta
+			} else if strings.HasPrefix(line, "Serie ") {
+				if len(copiarCartas()) < 5 {
 					color.Red("Você não tem cartas suficientes para montar um deck")
 				} else {
-					enviarRequisicao(conn, Requisicao{Tipo: "Batalhar", Id_remetente: idPessoal, Id_destinatario: idParceiro, Mensagem: "None"})
+					//Número de jogos e regras opcionais, ex: "Serie 3 Rapida"
+					enviarRequisicao(conn, Requisicao{Tipo: "Batalhar_Serie", Id_remetente: idPessoal, Id_destinatario: idParceiro, Mensagem: strings.TrimPrefix(line, "Serie ")})
 					estadoAtual = EstadoEsperandoResposta
 				}
 			} else if strings.HasPrefix(line, "Mensagem ") {
 				mensagem := strings.TrimPrefix(line, "Mensagem ")
 				enviarRequisicao(conn, Requisicao{Tipo: "Mensagem", Id_remetente: idPessoal, Id_destinatario: idParceiro, Mensagem: mensagem})
 
+			} else if tratarComandoTroca(conn, line) {
+				//Comando de troca tratado
+			} else if line == "Deck" {
+				estadoAnterior = EstadoPareado
+				estadoAtual = EstadoEditandoDeck
+			} else if strings.HasPrefix(line, "Assistir ") {
+				idBatalha := strings.TrimPrefix(line, "Assistir ")
+				enviarRequisicao(conn, Requisicao{Tipo: "Assistir", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: idBatalha})
+				estadoAtual = EstadoEsperandoResposta
+			} else if line == "Ajuda" {
+				imprimirAjuda(true)
+			} else if tratarComandoGeral(conn, line) {
+				//Comando de consulta tratado
+			} else if tratarComandoMercado(conn, line) {
+				//Comando do mercado tratado
+			} else if tratarComandoTorneio(conn, line) {
+				//Comando de torneio tratado
+			} else if tratarComandoChat(conn, line) {
+				//Comando de chat tratado
+			} else if tratarComandoAmigos(conn, line) {
+				//Comando de amizade tratado
 			} else if strings.HasPrefix(line, "Latencia") {
 				estadoAnterior = EstadoPareado
 				estadoAtual = EstadoMostrandoLatencia
@@ -235,14 +725,82 @@ func main() {
 
 		case EstadoBatalhando:
 			color.Yellow("Batalha ocorrendo!!")
-			time.Sleep(5 * time.Second)
+			//Espera até um pedido de carta ou 5s para imprimir novamente
+			select {
+			case <-pedidoCarta:
+			case <-time.After(5 * time.Second):
+			}
+
+		case EstadoAssistindo:
+			color.Yellow("Assistindo batalha, digite Sair para parar de assistir")
+
+			//Espectador apenas acompanha os turnos, a batalha pode terminar enquanto espera um comando
+			var line string
+			select {
+			case line = <-entradaTerminal:
+			case <-pedidoCarta:
+				continue
+			}
+
+			if line == "Sair" {
+				enviarRequisicao(conn, Requisicao{Tipo: "Parar_Assistir", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+				estadoAtual = EstadoEsperandoResposta
+			} else {
+				color.Red("Comando inválido")
+			}
+
+		case EstadoEscolhendoCarta:
+			if tipoEscolha == "Alvo" {
+				color.Cyan("Escolha o tanque oponente para atacar (%ds restantes):", int(time.Until(prazoEscolha).Seconds()))
+			} else {
+				color.Cyan("Escolha o próximo tanque (%ds restantes):", int(time.Until(prazoEscolha).Seconds()))
+			}
+			imprimirTanques(cartasDisponiveis)
+			fmt.Println("Digite o número do tanque: ")
+
+			select {
+			case line := <-entradaTerminal:
+				numero, err := strconv.Atoi(line)
+				if err != nil || numero < 1 || numero > len(cartasDisponiveis) {
+					color.Red("Número de tanque inválido")
+					continue
+				}
+
+				//Servidor recebe o índice da carta dentro da lista de disponíveis
+				enviarRequisicao(conn, Requisicao{
+					Tipo:            tipoEscolha,
+					Id_remetente:    idPessoal,
+					Id_destinatario: idParceiro,
+					Mensagem:        fmt.Sprintf("%d", numero-1),
+					Carta:           cartasDisponiveis[numero-1]})
+				estadoAtual = EstadoBatalhando
+
+			case <-time.After(time.Until(prazoEscolha)):
+				color.Red("Tempo para escolher esgotado!")
+				estadoAtual = EstadoBatalhando
+			}
+
+		case EstadoEditandoDeck:
+			color.Cyan("Editor de deck")
+
+			//Tela de edição com a sua própria leitura do terminal
+			enviado := editarDeck(conn, entradaTerminal, pedidoCarta)
+
+			//Se a batalha começou durante a edição o estado já foi trocado
+			if estadoAtual == EstadoEditandoDeck {
+				if enviado {
+					estadoAtual = EstadoEsperandoResposta
+				} else {
+					estadoAtual = estadoAnterior
+				}
+			}
 
 		case EstadoMostrandoLatencia:
 			color.Cyan("Medindo Latência (UDP Ping/Pong)")
 			fmt.Println("Digite Sair para voltar")
 
 			//Função para mandar continuamente requisições "ping"
-			iniciarLoopDeLatencia("server:8081", reader)
+			iniciarLoopDeLatencia("server:8081", entradaTerminal)
 
 			//Quando função terminar, devido opção de sair, voltar ao estado anterior
 			estadoAtual = estadoAnterior
@@ -253,6 +811,107 @@ func main() {
 	}
 }
 
+// Função para tratar comandos de consulta disponíveis tanto livre quanto pareado.
+// Retorna false se a linha não for um desses comandos
+func tratarComandoGeral(conn net.Conn, line string) bool {
+	if line == "Regras" {
+		enviarRequisicao(conn, Requisicao{Tipo: "Listar_Regras", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+	} else if line == "Decks" {
+		enviarRequisicao(conn, Requisicao{Tipo: "Listar_Decks", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+	} else if strings.HasPrefix(line, "Selecionar ") {
+		nome := strings.TrimPrefix(line, "Selecionar ")
+		enviarRequisicao(conn, Requisicao{Tipo: "Selecionar_Deck", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: nome})
+	} else if line == "Campanha" {
+		enviarRequisicao(conn, Requisicao{Tipo: "Campanha", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+	} else if strings.HasPrefix(line, "Missao ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Missao", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Missao ")})
+	} else if line == "IA" || strings.HasPrefix(line, "IA ") {
+		//Dificuldade e regras opcionais, ex: "IA Dificil Rapida"
+		enviarRequisicao(conn, Requisicao{Tipo: "Batalhar_IA", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimSpace(strings.TrimPrefix(line, "IA"))})
+	} else if strings.HasPrefix(line, "Ranking") {
+		//Quantidade opcional de jogadores, ex: "Ranking 20"
+		quantidade := strings.TrimSpace(strings.TrimPrefix(line, "Ranking"))
+		enviarRequisicao(conn, Requisicao{Tipo: "Ranking", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: quantidade})
+	} else if strings.HasPrefix(line, "Replay ") {
+		//Id da partida e velocidade opcional, ex: "Replay 4 2"
+		argumentos := strings.TrimPrefix(line, "Replay ")
+		enviarRequisicao(conn, Requisicao{Tipo: "Replay", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: argumentos})
+	} else if strings.HasPrefix(line, "Procedencia ") {
+		//Posição da carta na coleção, ex: "Procedencia 2"
+		cartas, err := cartasNasPosicoes(strings.TrimPrefix(line, "Procedencia "), copiarCartas())
+		if err != nil || len(cartas) != 1 || cartas[0].Instancia == 0 {
+			color.Red("Escolha uma carta da sua coleção")
+			return true
+		}
+		enviarRequisicao(conn, Requisicao{Tipo: "Procedencia", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strconv.Itoa(cartas[0].Instancia)})
+	} else if strings.HasPrefix(line, "Fundir ") {
+		//Posições das cópias, a primeira é a que evolui, ex: "Fundir 2,5,7"
+		cartas, err := cartasNasPosicoes(strings.TrimPrefix(line, "Fundir "), copiarCartas())
+		if err != nil {
+			color.Red(err.Error())
+			return true
+		}
+		enviarRequisicao(conn, Requisicao{Tipo: "Fundir", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None", Cartas: cartas})
+	} else if strings.HasPrefix(line, "Desmontar ") {
+		//Posições das cartas para virar sucata, ex: "Desmontar 1,4"
+		cartas, err := cartasNasPosicoes(strings.TrimPrefix(line, "Desmontar "), copiarCartas())
+		if err != nil {
+			color.Red(err.Error())
+			return true
+		}
+		enviarRequisicao(conn, Requisicao{Tipo: "Desmontar", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None", Cartas: cartas})
+	} else if strings.HasPrefix(line, "Fabricar ") {
+		//Nome do modelo do catálogo, ex: "Fabricar Tiger II"
+		enviarRequisicao(conn, Requisicao{Tipo: "Fabricar", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Fabricar ")})
+	} else if line == "Catalogo" {
+		enviarRequisicao(conn, Requisicao{Tipo: "Catalogo", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+	} else if strings.HasPrefix(line, "Temporada") {
+		//Número opcional de uma temporada encerrada para ver o ranking final, ex: "Temporada 1"
+		numero := strings.TrimSpace(strings.TrimPrefix(line, "Temporada"))
+		enviarRequisicao(conn, Requisicao{Tipo: "Temporada", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: numero})
+	} else if line == "Missoes" {
+		enviarRequisicao(conn, Requisicao{Tipo: "Missoes", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+	} else if line == "Conquistas" {
+		enviarRequisicao(conn, Requisicao{Tipo: "Conquistas", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+	} else if line == "Saldo" {
+		enviarRequisicao(conn, Requisicao{Tipo: "Saldo", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+	} else if line == "Batalhas" {
+		enviarRequisicao(conn, Requisicao{Tipo: "Batalhas_Ativas", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+	} else if strings.HasPrefix(line, "Historico") {
+		//Quantidade opcional de partidas, ex: "Historico 5"
+		quantidade := strings.TrimSpace(strings.TrimPrefix(line, "Historico"))
+		enviarRequisicao(conn, Requisicao{Tipo: "Historico", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: quantidade})
+	} else if strings.HasPrefix(line, "Estatisticas") {
+		//Id opcional de outro jogador, ex: "Estatisticas 3"
+		id := strings.TrimSpace(strings.TrimPrefix(line, "Estatisticas"))
+		enviarRequisicao(conn, Requisicao{Tipo: "Estatisticas", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: id})
+	} else {
+		return false
+	}
+	return true
+}
+
+// Função para adicionar cartas recebidas à coleção
+func adicionarCartas(cartas []Tanque) {
+	muCartas.Lock()
+	defer muCartas.Unlock()
+	minhasCartas = append(minhasCartas, cartas...)
+}
+
+// Função para substituir a coleção pela versão atualizada enviada pelo servidor
+func trocarCartas(cartas []Tanque) {
+	muCartas.Lock()
+	defer muCartas.Unlock()
+	minhasCartas = cartas
+}
+
+// Função para pegar uma cópia da coleção, segura para usar fora do lock
+func copiarCartas() []Tanque {
+	muCartas.Lock()
+	defer muCartas.Unlock()
+	return append([]Tanque(nil), minhasCartas...)
+}
+
 // Função para enviar requisição através de um pacote formato json via conexão TCP
 func enviarRequisicao(conn net.Conn, requisicao Requisicao) {
 	requisicao_json, _ := json.Marshal(requisicao)
@@ -260,53 +919,59 @@ func enviarRequisicao(conn net.Conn, requisicao Requisicao) {
 }
 
 // Função para ler da conexão uma resposta do servidor e transformar de volta em struct
-func lerResposta(conn net.Conn) Resposta {
-	reader := bufio.NewReader(conn)
-	mensagem, _ := reader.ReadBytes('\n')
+func lerResposta(reader *bufio.Reader) (Resposta, error) {
+	mensagem, err := reader.ReadBytes('\n')
 	var resposta Resposta
+	if err != nil {
+		return resposta, err
+	}
 	json.Unmarshal(mensagem, &resposta)
-	return resposta
+	return resposta, nil
 }
 
-// Função para sortear 5 cartas da coleção de cartas do jogador
-func sortearDeck() []Tanque {
-	//Cria um gerador aleatório independente usando tempo da chamada da função
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-
-	//Sorteia 5 índices usando o gerador independente
-	n := len(minhasCartas)
-	indices := r.Perm(n)[:5]
-
-	deck := make([]Tanque, 0, 5)
-	for _, i := range indices {
-		deck = append(deck, minhasCartas[i])
+// Função para verificar se o id está na lista
+func contem(lista []string, id string) bool {
+	for _, item := range lista {
+		if item == id {
+			return true
+		}
 	}
+	return false
+}
 
-	return deck
+// Função para iniciar a goroutine que lê continuamente do terminal e manda as linhas para um canal
+func iniciarLeituraTerminal(reader *bufio.Reader) <-chan string {
+	entrada := make(chan string)
+	go func() {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				//Fim da entrada (ex: Ctrl+D ou arquivo redirecionado), não há mais comandos para ler
+				color.Yellow("Entrada do terminal encerrada, saindo")
+				os.Exit(0)
+			}
+			entrada <- strings.TrimSpace(line)
+		}
+	}()
+	return entrada
 }
 
 // Função para imprimir a lista de tanques/cartas
 func imprimirTanques(lista []Tanque) {
 	for i, t := range lista {
 		fmt.Printf("Tanque %d:\n", i+1)
-		fmt.Printf("  Modelo: %s\n", t.Modelo)
+		fmt.Printf("  Modelo: %s\n", nomeCarta(t))
 		color.Yellow("  Jogador: %s", t.Id_jogador)
 		color.Green("  Vida: %d", t.Vida)
 		color.Red("  Ataque: %d", t.Ataque)
+		if t.Estrelas > 0 {
+			color.Cyan("  Estrelas: %s  Nível: %d (%d/%d XP)", strings.Repeat("*", t.Estrelas), t.Nivel, t.Experiencia, ExperienciaNivel)
+		}
 	}
 }
 
 // Função para criar loop for para mandar continuamente requisições de ping para o servidor
-func iniciarLoopDeLatencia(endereco string, reader *bufio.Reader) {
-	// Cria um canal para receber a entrada do usuário da goroutine centralizada do terminal
-	inputChan := make(chan string)
-
-	//Goroutine anônima para pegar entrada do terminal e mandar para o canal de comunicação
-	go func() {
-		line, _ := reader.ReadString('\n')
-		inputChan <- strings.TrimSpace(line)
-	}()
-
+func iniciarLoopDeLatencia(endereco string, inputChan <-chan string) {
 	//Rótulo usado para pode sair do loop for mesmo em alguma parte do escopo do "select"
 loop:
 	for {
@@ -317,12 +982,6 @@ loop:
 				break loop //Sai do loop for
 			}
 
-			//Reinicia a goroutine para conseguir ler o canal novamente
-			go func() {
-				line, _ := reader.ReadString('\n')
-				inputChan <- strings.TrimSpace(line)
-			}()
-
 		//Caso nada foi digitado pelo terminal dentro de 1s, realiza medição de latência
 		case <-time.After(1 * time.Second):
 			latencia, err := medirLatenciaUnica(endereco)
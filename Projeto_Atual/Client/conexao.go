@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Conexão com o servidor que pode ser trocada por uma nova depois de uma queda.
+// Implementa net.Conn para ser usada no lugar da conexão original
+type Conexao struct {
+	mu   sync.RWMutex
+	conn net.Conn
+}
+
+// Constantes da reconexão automática
+const (
+	EnderecoServidor   = "server:8080"
+	MaxTentativas      = 8
+	EsperaInicial      = 1 * time.Second
+	EsperaMaxima       = 8 * time.Second
+	TempoLimiteConexao = 3 * time.Second
+)
+
+// Token da sessão, usado para reconectar com o mesmo id
+var tokenSessao string
+
+// Função para pegar a conexão em uso
+func (c *Conexao) atual() net.Conn {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conn
+}
+
+// Função para trocar a conexão em uso, fechando a antiga
+func (c *Conexao) trocar(nova net.Conn) {
+	c.mu.Lock()
+	antiga := c.conn
+	c.conn = nova
+	c.mu.Unlock()
+	antiga.Close()
+}
+
+func (c *Conexao) Read(b []byte) (int, error)         { return c.atual().Read(b) }
+func (c *Conexao) Write(b []byte) (int, error)        { return c.atual().Write(b) }
+func (c *Conexao) Close() error                       { return c.atual().Close() }
+func (c *Conexao) LocalAddr() net.Addr                { return c.atual().LocalAddr() }
+func (c *Conexao) RemoteAddr() net.Addr               { return c.atual().RemoteAddr() }
+func (c *Conexao) SetDeadline(t time.Time) error      { return c.atual().SetDeadline(t) }
+func (c *Conexao) SetReadDeadline(t time.Time) error  { return c.atual().SetReadDeadline(t) }
+func (c *Conexao) SetWriteDeadline(t time.Time) error { return c.atual().SetWriteDeadline(t) }
+
+// Função para tentar reconectar ao servidor com espera crescente entre as tentativas.
+// Retorna o leitor da conexão nova e a resposta do servidor ao pedido de reconexão
+func reconectarServidor(c *Conexao) (*bufio.Reader, Resposta, error) {
+	espera := EsperaInicial
+	for tentativa := 1; tentativa <= MaxTentativas; tentativa++ {
+		color.Yellow("Conexão perdida, tentando reconectar (%d/%d)...", tentativa, MaxTentativas)
+
+		nova, err := net.DialTimeout("tcp", EnderecoServidor, TempoLimiteConexao)
+		if err == nil {
+			reader := bufio.NewReader(nova)
+
+			//Servidor sempre envia um id e um token novos ao conectar
+			criacao, errId := lerResposta(reader)
+			sessao, errSessao := lerResposta(reader)
+			if errId == nil && errSessao == nil && criacao.Tipo == "Criaçao_Id" && sessao.Tipo == "Sessao" {
+				c.trocar(nova)
+				enviarRequisicao(c, Requisicao{Tipo: "Reconectar", Id_remetente: criacao.Mensagem, Id_destinatario: "None", Mensagem: tokenSessao})
+
+				//Avisos da identidade temporária, como o bônus diário, são ignorados
+				resposta, err := lerResposta(reader)
+				for err == nil && resposta.Tipo != "Reconectado" && resposta.Tipo != "Erro" {
+					resposta, err = lerResposta(reader)
+				}
+				if err == nil && resposta.Tipo == "Erro" {
+					//Sessão antiga expirou, continua com a identidade nova
+					resposta = Resposta{Tipo: "Nova_Sessao", Mensagem: criacao.Mensagem}
+					tokenSessao = sessao.Mensagem
+				}
+				if err == nil {
+					return reader, resposta, nil
+				}
+			}
+			nova.Close()
+		}
+
+		time.Sleep(espera)
+		espera = min(espera*2, EsperaMaxima)
+	}
+	return nil, Resposta{}, errors.New("não foi possível reconectar ao servidor")
+}
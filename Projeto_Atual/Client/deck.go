@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Deck salvo no servidor
+type Deck struct {
+	Nome   string   `json:"nome"`
+	Cartas []Tanque `json:"cartas"`
+}
+
+// Ids de instância das cartas do deck que está sendo editado, protegidos por muCartas.
+// Guardar ids e não posições mantém o deck certo quando a coleção muda durante a edição
+var deckEmEdicao []int
+
+// Função da tela interativa de edição de deck, retorna true se o deck foi enviado para o servidor
+func editarDeck(conn net.Conn, entradaTerminal <-chan string, pedidoCarta <-chan bool) bool {
+	for {
+		colecao := copiarCartas()
+		deck := cartasDeckEmEdicao()
+		color.Cyan("Sua coleção:")
+		imprimirResumoTanques(colecao)
+		color.Cyan("Deck em edição (%d cartas):", len(deck))
+		imprimirResumoTanques(deck)
+		fmt.Println("Comando Adicionar <n> / Remover <n> / Limpar / Salvar <nome> / Sair: ")
+
+		//Batalha pode ser iniciada pelo jogador pareado durante a edição
+		var line string
+		select {
+		case line = <-entradaTerminal:
+		case <-pedidoCarta:
+			return false
+		}
+
+		if line == "Sair" {
+			return false
+		}
+
+		if strings.HasPrefix(line, "Adicionar ") {
+			//Posição na coleção mostrada acima, a carta é guardada pelo id de instância
+			numero, err := strconv.Atoi(strings.TrimPrefix(line, "Adicionar "))
+			if err != nil || numero < 1 || numero > len(colecao) {
+				color.Red("Número de carta inválido")
+			} else if err := adicionarAoDeck(colecao[numero-1]); err != nil {
+				color.Red(err.Error())
+			}
+		} else if strings.HasPrefix(line, "Remover ") {
+			numero, err := strconv.Atoi(strings.TrimPrefix(line, "Remover "))
+			if err != nil || !removerDoDeck(numero-1) {
+				color.Red("Número de carta inválido")
+			}
+		} else if line == "Limpar" {
+			limparDeckEmEdicao()
+		} else if strings.HasPrefix(line, "Salvar ") {
+			//Tamanho do deck é validado pelo servidor com as regras de batalha existentes
+			nome := strings.TrimSpace(strings.TrimPrefix(line, "Salvar "))
+			enviarRequisicao(conn, Requisicao{Tipo: "Criar_Deck", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: nome, Cartas: cartasDeckEmEdicao()})
+			return true
+		} else {
+			color.Red("Comando inválido")
+		}
+	}
+}
+
+// Função para adicionar uma carta da coleção ao deck em edição
+func adicionarAoDeck(carta Tanque) error {
+	muCartas.Lock()
+	defer muCartas.Unlock()
+
+	if carta.Instancia == 0 {
+		return fmt.Errorf("Essa carta não pode entrar em um deck")
+	}
+	for _, instancia := range deckEmEdicao {
+		if instancia == carta.Instancia {
+			return fmt.Errorf("Essa carta já está no deck")
+		}
+	}
+	deckEmEdicao = append(deckEmEdicao, carta.Instancia)
+	return nil
+}
+
+// Função para remover do deck em edição a carta na posição informada, false se a posição não existe
+func removerDoDeck(posicao int) bool {
+	muCartas.Lock()
+	defer muCartas.Unlock()
+
+	if posicao < 0 || posicao >= len(deckEmEdicao) {
+		return false
+	}
+	deckEmEdicao = append(deckEmEdicao[:posicao], deckEmEdicao[posicao+1:]...)
+	return true
+}
+
+// Função para esvaziar o deck em edição
+func limparDeckEmEdicao() {
+	muCartas.Lock()
+	defer muCartas.Unlock()
+	deckEmEdicao = nil
+}
+
+// Função para pegar as cartas do deck em edição na coleção atual.
+// Cartas que saíram da coleção (troca, venda, fusão) são retiradas do deck
+func cartasDeckEmEdicao() []Tanque {
+	muCartas.Lock()
+	defer muCartas.Unlock()
+
+	porInstancia := make(map[int]Tanque, len(minhasCartas))
+	for _, carta := range minhasCartas {
+		porInstancia[carta.Instancia] = carta
+	}
+
+	cartas := make([]Tanque, 0, len(deckEmEdicao))
+	restantes := deckEmEdicao[:0]
+	for _, instancia := range deckEmEdicao {
+		carta, existe := porInstancia[instancia]
+		if !existe {
+			color.Yellow("Uma carta do deck saiu da sua coleção e foi retirada do deck")
+			continue
+		}
+		cartas = append(cartas, carta)
+		restantes = append(restantes, instancia)
+	}
+	deckEmEdicao = restantes
+	return cartas
+}
+
+// Função para imprimir os decks salvos, marcando o selecionado
+func imprimirDecks(lista []Deck, selecionado string) {
+	if len(lista) == 0 {
+		color.Yellow("Nenhum deck salvo")
+		return
+	}
+	for _, d := range lista {
+		if d.Nome == selecionado {
+			color.Green("* %s (selecionado)", d.Nome)
+		} else {
+			color.Cyan("  %s", d.Nome)
+		}
+		imprimirResumoTanques(d.Cartas)
+	}
+}
+
+// Função para imprimir uma lista de tanques em uma linha por carta
+func imprimirResumoTanques(lista []Tanque) {
+	for i, t := range lista {
+		fmt.Printf("  %d. %s (Vida %d / Ataque %d)\n", i+1, nomeCarta(t), t.Vida, t.Ataque)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Resumo de uma batalha em andamento recebido do servidor
+type ResumoBatalha struct {
+	Id           int      `json:"id"`
+	Modo         string   `json:"modo"`
+	Regras       string   `json:"regras"`
+	Jogadores    []string `json:"jogadores"`
+	UltimoTurno  string   `json:"ultimo_turno"`
+	Espectadores int      `json:"espectadores"`
+}
+
+// Função para imprimir as batalhas em andamento que podem ser assistidas
+func imprimirBatalhasAtivas(lista []ResumoBatalha) {
+	if len(lista) == 0 {
+		color.Yellow("Nenhuma batalha em andamento")
+		return
+	}
+	color.Cyan("Batalhas em andamento (use Assistir <id>):")
+	for _, b := range lista {
+		fmt.Printf("   Batalha %-4d %s (%s)  jogadores %s  espectadores %d\n", b.Id, b.Modo, b.Regras, strings.Join(b.Jogadores, ", "), b.Espectadores)
+		if b.UltimoTurno != "" {
+			fmt.Printf("      Último turno: %s\n", b.UltimoTurno)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Participação de um jogador em uma partida do histórico
+type JogadorPartida struct {
+	Id     string   `json:"id"`
+	Equipe int      `json:"equipe"`
+	Deck   []Tanque `json:"deck"`
+	Usadas []string `json:"usadas"`
+	Dano   int      `json:"dano"`
+}
+
+// Partida encerrada recebida do servidor
+type Partida struct {
+	Id         int              `json:"id"`
+	Modo       string           `json:"modo"`
+	Regras     string           `json:"regras"`
+	Jogadores  []JogadorPartida `json:"jogadores"`
+	Turnos     int              `json:"turnos"`
+	Vencedores []string         `json:"vencedores"`
+	Motivo     string           `json:"motivo"`
+	Inicio     time.Time        `json:"inicio"`
+	Duracao    float64          `json:"duracao"`
+}
+
+// Estatísticas de um jogador recebidas do servidor
+type Estatisticas struct {
+	Id             string   `json:"id"`
+	Partidas       int      `json:"partidas"`
+	Vitorias       int      `json:"vitorias"`
+	Derrotas       int      `json:"derrotas"`
+	Empates        int      `json:"empates"`
+	TaxaVitoria    float64  `json:"taxa_vitoria"`
+	TanqueFavorito string   `json:"tanque_favorito"`
+	UsosFavorito   int      `json:"usos_favorito"`
+	MediaTurnos    float64  `json:"media_turnos"`
+	MediaDuracao   float64  `json:"media_duracao"`
+	Conquistas     []string `json:"conquistas"`
+}
+
+// Função para imprimir as partidas do histórico com o resultado do ponto de vista do jogador
+func imprimirHistorico(partidas []Partida, total string) {
+	if len(partidas) == 0 {
+		color.Yellow("Nenhuma partida no histórico")
+		return
+	}
+	color.Cyan("Últimas %d de %s partidas:", len(partidas), total)
+
+	for _, p := range partidas {
+		resultado := "Derrota"
+		if contem(p.Vencedores, idPessoal) {
+			resultado = "Vitória"
+		} else if len(p.Vencedores) == 0 {
+			resultado = "Empate"
+		}
+
+		cabecalho := fmt.Sprintf("#%d %s  %s (%s)  %d turnos em %.0fs  %s - %s",
+			p.Id, p.Inicio.Format("02/01 15:04"), p.Modo, p.Regras, p.Turnos, p.Duracao, resultado, p.Motivo)
+		if resultado == "Vitória" {
+			color.Green(cabecalho)
+		} else {
+			color.Red(cabecalho)
+		}
+
+		for _, j := range p.Jogadores {
+			modelos := make([]string, 0, len(j.Deck))
+			for _, t := range j.Deck {
+				modelos = append(modelos, t.Modelo)
+			}
+			fmt.Printf("   Jogador %-6s equipe %d  dano %3d  deck [%s]  usadas [%s]\n",
+				j.Id, j.Equipe, j.Dano, strings.Join(modelos, ", "), strings.Join(j.Usadas, ", "))
+		}
+	}
+}
+
+// Função para imprimir as estatísticas de um jogador
+func imprimirEstatisticas(e *Estatisticas) {
+	if e == nil {
+		return
+	}
+	if e.Partidas > 0 {
+		color.Cyan("Estatísticas do jogador %s:", e.Id)
+		fmt.Printf("   Partidas: %d (%dV / %dD / %dE)\n", e.Partidas, e.Vitorias, e.Derrotas, e.Empates)
+		fmt.Printf("   Taxa de vitória: %.1f%%\n", e.TaxaVitoria)
+		if e.TanqueFavorito != "" {
+			fmt.Printf("   Tanque favorito: %s (%d vezes em campo)\n", e.TanqueFavorito, e.UsosFavorito)
+		}
+		fmt.Printf("   Duração média: %.1f turnos / %.0fs\n", e.MediaTurnos, e.MediaDuracao)
+	}
+	if len(e.Conquistas) > 0 {
+		color.Cyan("   Conquistas: %s", strings.Join(e.Conquistas, ", "))
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Anúncio de carta no mercado recebido do servidor
+type Anuncio struct {
+	Id        int       `json:"id"`
+	Vendedor  string    `json:"vendedor"`
+	Carta     Tanque    `json:"carta"`
+	Preco     int       `json:"preco"`
+	Leilao    bool      `json:"leilao"`
+	Lance     int       `json:"lance"`
+	Licitante string    `json:"licitante"`
+	Fim       time.Time `json:"fim"`
+}
+
+// Função para tratar os comandos do mercado, retorna false se não for um deles
+func tratarComandoMercado(conn net.Conn, line string) bool {
+	if strings.HasPrefix(line, "Anunciar ") || strings.HasPrefix(line, "Leiloar ") {
+		//Posição da carta seguida do preço, ex: "Anunciar 2 150", ou do lance mínimo e duração, ex: "Leiloar 2 100 60"
+		campos := strings.Fields(line)
+		leilao := campos[0] == "Leiloar"
+		if (!leilao && len(campos) != 3) || (leilao && len(campos) != 4) {
+			color.Red("Use Anunciar <carta> <preço> ou Leiloar <carta> <lance mínimo> <segundos>")
+			return true
+		}
+		cartas, err := cartasNasPosicoes(campos[1], copiarCartas())
+		if err != nil || len(cartas) != 1 {
+			color.Red("Escolha uma carta da sua coleção")
+			return true
+		}
+		enviarRequisicao(conn, Requisicao{Tipo: "Anunciar", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.Join(campos[2:], " "), Cartas: cartas})
+	} else if line == "Anuncios" {
+		enviarRequisicao(conn, Requisicao{Tipo: "Listar_Anuncios", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+	} else if strings.HasPrefix(line, "Comprar ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Comprar", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Comprar ")})
+	} else if strings.HasPrefix(line, "Lance ") {
+		//Id do leilão e valor, ex: "Lance 3 120"
+		enviarRequisicao(conn, Requisicao{Tipo: "Dar_Lance", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Lance ")})
+	} else if strings.HasPrefix(line, "Retirar ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Retirar_Anuncio", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Retirar ")})
+	} else {
+		return false
+	}
+	return true
+}
+
+// Função para imprimir a lista de anúncios abertos
+func imprimirAnuncios(lista []Anuncio) {
+	if len(lista) == 0 {
+		color.Yellow("Nenhum anúncio aberto")
+		return
+	}
+	color.Cyan("Anúncios abertos:")
+	for _, a := range lista {
+		carta := fmt.Sprintf("%s (Vida %d / Ataque %d)", nomeCarta(a.Carta), a.Carta.Vida, a.Carta.Ataque)
+		restante := time.Until(a.Fim).Round(time.Second)
+		if !a.Leilao {
+			fmt.Printf("  %d. %s por %d moedas, vendedor %s, %s restantes\n", a.Id, carta, a.Preco, a.Vendedor, restante)
+		} else if a.Licitante == "" {
+			fmt.Printf("  %d. Leilão de %s, lance mínimo %d moedas, vendedor %s, %s restantes\n", a.Id, carta, a.Preco, a.Vendedor, restante)
+		} else {
+			fmt.Printf("  %d. Leilão de %s, maior lance %d moedas de %s, vendedor %s, %s restantes\n", a.Id, carta, a.Lance, a.Licitante, a.Vendedor, restante)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+)
+
+// Progresso de uma missão diária ou conquista recebido do servidor
+type ProgressoMissao struct {
+	Nome       string `json:"nome"`
+	Descricao  string `json:"descricao"`
+	Progresso  int    `json:"progresso"`
+	Meta       int    `json:"meta"`
+	Recompensa string `json:"recompensa"`
+	Concluida  bool   `json:"concluida"`
+}
+
+// Função para imprimir a lista de missões ou conquistas com o progresso de cada uma
+func imprimirMissoes(titulo string, lista []ProgressoMissao) {
+	color.Cyan("%s:", titulo)
+	for _, m := range lista {
+		linha := fmt.Sprintf("  [%d/%d] %s - %s", m.Progresso, m.Meta, m.Nome, m.Descricao)
+		if m.Recompensa != "" {
+			linha += fmt.Sprintf(" (recompensa: %s)", m.Recompensa)
+		}
+		if m.Concluida {
+			color.Green(linha + " - concluída")
+		} else {
+			fmt.Println(linha)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Linha da tabela de ranking recebida do servidor
+type PosicaoRanking struct {
+	Posicao  int    `json:"posicao"`
+	Id       string `json:"id"`
+	Rating   int    `json:"rating"`
+	Vitorias int    `json:"vitorias"`
+	Derrotas int    `json:"derrotas"`
+	Empates  int    `json:"empates"`
+}
+
+// Temporada ranqueada recebida do servidor
+type Temporada struct {
+	Numero int       `json:"numero"`
+	Nome   string    `json:"nome"`
+	Inicio time.Time `json:"inicio"`
+	Fim    time.Time `json:"fim"`
+}
+
+// Temporada atual, atualizada ao conectar e quando uma nova começa
+var temporadaAtual *Temporada
+
+// Função para imprimir a temporada atual com o tempo restante calculado na hora
+func imprimirTemporada() {
+	if temporadaAtual == nil {
+		return
+	}
+	restante := max(time.Until(temporadaAtual.Fim), 0).Round(time.Second)
+	color.Cyan("Temporada %d (%s): %s restantes", temporadaAtual.Numero, temporadaAtual.Nome, restante)
+}
+
+// Função para imprimir a tabela de ranking, destacando o próprio jogador
+func imprimirRanking(tabela []PosicaoRanking) {
+	if len(tabela) == 0 {
+		color.Yellow("Nenhuma partida ranqueada ainda")
+		return
+	}
+	for _, p := range tabela {
+		linha := fmt.Sprintf("%3dº  Jogador %-6s  Rating %5d  (%dV / %dD / %dE)", p.Posicao, p.Id, p.Rating, p.Vitorias, p.Derrotas, p.Empates)
+		if p.Id == idPessoal {
+			color.Green(linha)
+		} else {
+			fmt.Println(linha)
+		}
+	}
+}
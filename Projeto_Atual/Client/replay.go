@@ -0,0 +1,127 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Evento público de uma batalha guardado no replay
+type EventoReplay struct {
+	Tempo    int64    `json:"t"` //Milissegundos desde o início da batalha
+	Tipo     string   `json:"tipo"`
+	Mensagem string   `json:"msg"`
+	Cartas   []Tanque `json:"cartas,omitempty"`
+}
+
+// Regras da batalha guardadas no replay, os tempos estão em nanossegundos
+type RegrasReplay struct {
+	Nome         string
+	TamanhoDeck  int
+	TempoTurno   time.Duration
+	Ritmo        time.Duration
+	Vitoria      int
+	LimiteTurnos int
+}
+
+// Replay completo de uma batalha, no mesmo formato do arquivo salvo pelo servidor
+type Replay struct {
+	Id        int              `json:"id"`
+	Semente   int64            `json:"semente"`
+	Modo      string           `json:"modo"`
+	Regras    RegrasReplay     `json:"regras"`
+	Descricao string           `json:"descricao"`
+	Jogadores []JogadorPartida `json:"jogadores"`
+	Inicio    time.Time        `json:"inicio"`
+	Eventos   []EventoReplay   `json:"eventos,omitempty"`
+}
+
+// Replay sendo recebido do servidor, salvo em disco quando termina
+var replayRecebido *Replay
+
+// Função para imprimir o cabeçalho do replay com os jogadores e seus decks
+func imprimirCabecalhoReplay(r *Replay) {
+	color.Cyan("Replay da partida %d (%s) de %s", r.Id, r.Modo, r.Inicio.Format("02/01/2006 15:04"))
+	color.Cyan("Regras: %s", r.Descricao)
+	for _, j := range r.Jogadores {
+		modelos := make([]string, 0, len(j.Deck))
+		for _, t := range j.Deck {
+			modelos = append(modelos, t.Modelo)
+		}
+		fmt.Printf("   Jogador %-6s equipe %d  deck [%s]\n", j.Id, j.Equipe, strings.Join(modelos, ", "))
+	}
+}
+
+// Função para imprimir um evento do replay como seria visto durante a batalha
+func imprimirEventoReplay(e EventoReplay) {
+	switch e.Tipo {
+	case "Turno_Realizado":
+		color.Yellow("[%5.1fs] %s", float64(e.Tempo)/1000, e.Mensagem)
+		imprimirTanques(e.Cartas)
+	case "Fim_Batalha":
+		color.Cyan("[%5.1fs] %s", float64(e.Tempo)/1000, e.Mensagem)
+	default:
+		fmt.Printf("[%5.1fs] %s\n", float64(e.Tempo)/1000, e.Mensagem)
+	}
+}
+
+// Função para salvar em disco o replay recebido do servidor, para ser assistido sem conexão
+func salvarReplay(r *Replay) {
+	nome := fmt.Sprintf("replay_%d.json.gz", r.Id)
+	arquivo, err := os.Create(nome)
+	if err != nil {
+		color.Red("Erro ao salvar replay: %v", err)
+		return
+	}
+	defer arquivo.Close()
+
+	compactador := gzip.NewWriter(arquivo)
+	defer compactador.Close()
+	if err := json.NewEncoder(compactador).Encode(r); err != nil {
+		color.Red("Erro ao salvar replay: %v", err)
+		return
+	}
+	color.Green("Replay salvo em %s, assista sem conexão com: go run . -replay %s", nome, nome)
+}
+
+// Função para reproduzir um arquivo de replay do disco sem conectar ao servidor
+func reproduzirArquivoReplay(nome string, velocidade float64) {
+	if velocidade <= 0 {
+		color.Red("Velocidade deve ser maior que zero")
+		return
+	}
+
+	arquivo, err := os.Open(nome)
+	if err != nil {
+		color.Red("Erro ao abrir replay: %v", err)
+		return
+	}
+	defer arquivo.Close()
+
+	descompactador, err := gzip.NewReader(arquivo)
+	if err != nil {
+		color.Red("Arquivo de replay inválido: %v", err)
+		return
+	}
+	defer descompactador.Close()
+
+	var replay Replay
+	if err := json.NewDecoder(descompactador).Decode(&replay); err != nil {
+		color.Red("Arquivo de replay inválido: %v", err)
+		return
+	}
+
+	imprimirCabecalhoReplay(&replay)
+	anterior := int64(0)
+	for _, evento := range replay.Eventos {
+		time.Sleep(time.Duration(float64(evento.Tempo-anterior)/velocidade) * time.Millisecond)
+		anterior = evento.Tempo
+		imprimirEventoReplay(evento)
+	}
+	color.Cyan("Fim do replay")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Posição de um jogador na classificação do torneio recebida do servidor
+type ClassificacaoTorneio struct {
+	Posicao   int     `json:"posicao"`
+	Id        string  `json:"id"`
+	Pontos    float64 `json:"pontos"`
+	Vitorias  int     `json:"vitorias"`
+	Derrotas  int     `json:"derrotas"`
+	Empates   int     `json:"empates"`
+	Desempate float64 `json:"desempate"`
+}
+
+// Resumo de um torneio recebido do servidor
+type ResumoTorneio struct {
+	Id            int                    `json:"id"`
+	Organizador   string                 `json:"organizador"`
+	Formato       string                 `json:"formato"`
+	Regras        string                 `json:"regras"`
+	Inscritos     []string               `json:"inscritos"`
+	Rodada        int                    `json:"rodada"`
+	Rodadas       int                    `json:"rodadas"`
+	Estado        string                 `json:"estado"`
+	Classificacao []ClassificacaoTorneio `json:"classificacao"`
+}
+
+// Função para tratar os comandos de torneio, retorna false se não for um deles
+func tratarComandoTorneio(conn net.Conn, line string) bool {
+	if line == "Torneios" {
+		enviarRequisicao(conn, Requisicao{Tipo: "Listar_Torneios", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+	} else if strings.HasPrefix(line, "Torneio ") {
+		//Formato e regras opcionais, ex: "Torneio Suico Rapida"
+		enviarRequisicao(conn, Requisicao{Tipo: "Criar_Torneio", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Torneio ")})
+	} else if strings.HasPrefix(line, "Inscrever ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Inscrever", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Inscrever ")})
+	} else if strings.HasPrefix(line, "Iniciar ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Iniciar_Torneio", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Iniciar ")})
+	} else {
+		return false
+	}
+	return true
+}
+
+// Função para imprimir os torneios abertos ou em andamento
+func imprimirTorneios(lista []ResumoTorneio) {
+	if len(lista) == 0 {
+		color.Yellow("Nenhum torneio aberto")
+		return
+	}
+	color.Cyan("Torneios:")
+	for _, t := range lista {
+		fmt.Printf("  %d. %s com regras %s, organizado por %s, %s, %d inscrito(s): %s\n", t.Id, t.Formato, t.Regras, t.Organizador, t.Estado, len(t.Inscritos), strings.Join(t.Inscritos, ", "))
+		if len(t.Classificacao) > 0 {
+			fmt.Printf("     Rodada %d de %d\n", t.Rodada, t.Rodadas)
+		}
+	}
+}
+
+// Função para imprimir a classificação do torneio, destacando o próprio jogador
+func imprimirClassificacao(torneio ResumoTorneio) {
+	if len(torneio.Classificacao) == 0 {
+		return
+	}
+	color.Cyan("Classificação do torneio %d (%s):", torneio.Id, torneio.Formato)
+	for _, p := range torneio.Classificacao {
+		linha := fmt.Sprintf("%3dº  Jogador %-6s  (%dV / %dD / %dE)", p.Posicao, p.Id, p.Vitorias, p.Derrotas, p.Empates)
+		if torneio.Formato == "Suico" {
+			linha = fmt.Sprintf("%3dº  Jogador %-6s  %4.1f pontos  Desempate %4.1f  (%dV / %dD / %dE)", p.Posicao, p.Id, p.Pontos, p.Desempate, p.Vitorias, p.Derrotas, p.Empates)
+		}
+		if p.Id == idPessoal {
+			color.Green(linha)
+		} else {
+			fmt.Println(linha)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Proposta de troca de cartas recebida do servidor
+type Troca struct {
+	Id           int      `json:"id"`
+	Proponente   string   `json:"proponente"`
+	Destinatario string   `json:"destinatario"`
+	Oferecidas   []Tanque `json:"oferecidas"`
+	Pedidas      []Tanque `json:"pedidas"`
+}
+
+// Variáveis das trocas
+var colecaoVista []Tanque              //Última coleção de outro jogador recebida, usada para escolher as cartas pedidas
+var idColecaoVista string              //Dono da última coleção vista
+var trocasConhecidas = map[int]Troca{} //Trocas propostas para ou pelo jogador
+
+// Função para tratar os comandos de troca do estado pareado, retorna false se não for um deles
+func tratarComandoTroca(conn net.Conn, line string) bool {
+	if strings.HasPrefix(line, "Colecao") {
+		//Id opcional, por padrão a coleção do parceiro
+		id := strings.TrimSpace(strings.TrimPrefix(line, "Colecao"))
+		if id == "" {
+			id = idParceiro
+		}
+		enviarRequisicao(conn, Requisicao{Tipo: "Ver_Colecao", Id_remetente: idPessoal, Id_destinatario: id, Mensagem: "None"})
+	} else if strings.HasPrefix(line, "Trocar ") {
+		//Posições das suas cartas e das cartas do parceiro, ex: "Trocar 1,3 por 2"
+		oferecidas, pedidas, err := cartasDaTroca(strings.TrimPrefix(line, "Trocar "), idParceiro)
+		if err != nil {
+			color.Red(err.Error())
+			return true
+		}
+		enviarRequisicao(conn, Requisicao{Tipo: "Propor_Troca", Id_remetente: idPessoal, Id_destinatario: idParceiro, Mensagem: "None", Cartas: oferecidas, Pedidas: pedidas})
+	} else if strings.HasPrefix(line, "Contrapropor ") {
+		//Id da troca recebida seguido das cartas, ex: "Contrapropor 4 2 por 1,5"
+		campos := strings.SplitN(strings.TrimPrefix(line, "Contrapropor "), " ", 2)
+		idTroca, err := strconv.Atoi(campos[0])
+		troca, existe := trocasConhecidas[idTroca]
+		if err != nil || len(campos) < 2 || !existe || troca.Destinatario != idPessoal {
+			color.Red("Use Contrapropor <id da troca recebida> <suas cartas> por <cartas dele>")
+			return true
+		}
+		oferecidas, pedidas, err := cartasDaTroca(campos[1], troca.Proponente)
+		if err != nil {
+			color.Red(err.Error())
+			return true
+		}
+		enviarRequisicao(conn, Requisicao{Tipo: "Contrapropor_Troca", Id_remetente: idPessoal, Id_destinatario: troca.Proponente, Mensagem: campos[0], Cartas: oferecidas, Pedidas: pedidas})
+	} else if strings.HasPrefix(line, "Aceitar ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Aceitar_Troca", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Aceitar ")})
+	} else if strings.HasPrefix(line, "Cancelar ") {
+		enviarRequisicao(conn, Requisicao{Tipo: "Cancelar_Troca", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: strings.TrimPrefix(line, "Cancelar ")})
+	} else if line == "Trocas" {
+		enviarRequisicao(conn, Requisicao{Tipo: "Listar_Trocas", Id_remetente: idPessoal, Id_destinatario: "None", Mensagem: "None"})
+	} else {
+		return false
+	}
+	return true
+}
+
+// Função para separar as cartas oferecidas e pedidas de um comando "<suas posições> por <posições dele>".
+// Use "-" para não oferecer ou não pedir nenhuma carta
+func cartasDaTroca(texto, idOutro string) ([]Tanque, []Tanque, error) {
+	partes := strings.Split(texto, " por ")
+	if len(partes) != 2 {
+		return nil, nil, fmt.Errorf("Use <suas cartas> por <cartas dele>, ex: 1,3 por 2")
+	}
+	if idColecaoVista != idOutro {
+		return nil, nil, fmt.Errorf("Veja antes a coleção do jogador %s com Colecao %s", idOutro, idOutro)
+	}
+
+	oferecidas, err := cartasNasPosicoes(partes[0], copiarCartas())
+	if err != nil {
+		return nil, nil, err
+	}
+	pedidas, err := cartasNasPosicoes(partes[1], colecaoVista)
+	if err != nil {
+		return nil, nil, err
+	}
+	return oferecidas, pedidas, nil
+}
+
+// Função para pegar as cartas nas posições separadas por vírgula, começando em 1
+func cartasNasPosicoes(texto string, lista []Tanque) ([]Tanque, error) {
+	cartas := make([]Tanque, 0)
+	texto = strings.TrimSpace(texto)
+	if texto == "-" {
+		return cartas, nil
+	}
+
+	usadas := make(map[int]bool)
+	for _, campo := range strings.Split(texto, ",") {
+		numero, err := strconv.Atoi(strings.TrimSpace(campo))
+		if err != nil || numero < 1 || numero > len(lista) || usadas[numero] {
+			return nil, fmt.Errorf("Posição de carta inválida: %s", campo)
+		}
+		usadas[numero] = true
+		cartas = append(cartas, lista[numero-1])
+	}
+	return cartas, nil
+}
+
+// Função para imprimir uma proposta de troca
+func imprimirTroca(t Troca) {
+	color.Cyan("Troca %d: jogador %s oferece %d carta(s) ao jogador %s por %d carta(s)", t.Id, t.Proponente, len(t.Oferecidas), t.Destinatario, len(t.Pedidas))
+	fmt.Println("   Oferecidas:")
+	imprimirResumoTanques(t.Oferecidas)
+	fmt.Println("   Pedidas:")
+	imprimirResumoTanques(t.Pedidas)
+	if t.Destinatario == idPessoal {
+		color.Yellow("   Aceitar %d / Contrapropor %d <suas cartas> por <cartas dele> / Cancelar %d", t.Id, t.Id, t.Id)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Amigo ou pedido de amizade enviado na lista, com a presença atual
+type Amigo struct {
+	Id       string `json:"id"`
+	Apelido  string `json:"apelido"`
+	Presenca string `json:"presenca"`
+	Pendente bool   `json:"pendente"` //Pedido de amizade recebido e ainda não respondido
+}
+
+// Estados de presença, derivados das conexões, grupos, torneios e batalhas
+const (
+	PresencaOffline   = "Offline"
+	PresencaOnline    = "Online"
+	PresencaNaFila    = "Na fila"
+	PresencaEmBatalha = "Em batalha"
+	PresencaPareado   = "Pareado"
+)
+
+// Constantes dos amigos
+const (
+	MaxAmigos         = 50
+	IntervaloPresenca = 1 * time.Second //Intervalo para conferir mudanças de presença
+)
+
+// Variáveis dos amigos
+var (
+	amigos         = make(map[string]map[string]bool) //Amigos de cada jogador, a amizade fica nos dois lados
+	pedidosAmizade = make(map[string]map[string]bool) //Pedidos recebidos por cada jogador, pelo id de quem pediu
+	ultimaPresenca = make(map[string]string)          //Última presença avisada aos amigos de cada jogador
+	muAmigos       sync.Mutex                         //Mutex para sincronizar amigos e pedidos
+)
+
+// Função para calcular a presença do jogador, da atividade mais ocupada para a mais livre
+func presencaDe(id string) string {
+	muClientes.RLock()
+	_, online := clientes[id]
+	muClientes.RUnlock()
+
+	if !online {
+		return PresencaOffline
+	}
+	if emBatalha(id) {
+		return PresencaEmBatalha
+	}
+	if naFilaTorneio(id) {
+		return PresencaNaFila
+	}
+	if len(membrosGrupo(id)) > 0 {
+		return PresencaPareado
+	}
+	return PresencaOnline
+}
+
+// Função para verificar se o jogador está inscrito em um torneio não encerrado e ainda não foi eliminado
+func naFilaTorneio(id string) bool {
+	muTorneios.Lock()
+	defer muTorneios.Unlock()
+	for _, torneio := range torneios {
+		if torneio.Estado == TorneioEncerrado || !contem(torneio.Inscritos, id) {
+			continue
+		}
+		if jogador, existe := torneio.Jogadores[id]; !existe || jogador.Eliminado == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Função para montar o amigo com o apelido e a presença atuais
+func descreverAmigo(id string, pendente bool) Amigo {
+	return Amigo{Id: id, Apelido: apelidoDe(id), Presenca: presencaDe(id), Pendente: pendente}
+}
+
+// Função para enviar um pedido de amizade a um jogador online pelo id ou apelido.
+// Se o outro jogador já tinha pedido, a amizade é aceita na hora
+func pedirAmizade(conn net.Conn, id, alvo string) {
+	var resposta Resposta
+
+	destinatario, online := buscarJogadorOnline(alvo)
+	if !online {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Jogador %s não existe ou não está online", alvo)
+		enviarResposta(conn, resposta)
+		return
+	}
+	if destinatario == id {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Não é possível pedir amizade a si mesmo"
+		enviarResposta(conn, resposta)
+		return
+	}
+	if err := verificarBloqueio(id, destinatario); err != nil {
+		enviarErroModeracao(conn, err)
+		return
+	}
+
+	muAmigos.Lock()
+	if amigos[id][destinatario] {
+		resposta.Mensagem = fmt.Sprintf("Você já é amigo do jogador %s", destinatario)
+	} else if pedidosAmizade[destinatario][id] {
+		resposta.Mensagem = fmt.Sprintf("Você já pediu amizade ao jogador %s", destinatario)
+	} else if len(amigos[id]) >= MaxAmigos || len(amigos[destinatario]) >= MaxAmigos {
+		resposta.Mensagem = fmt.Sprintf("Limite de %d amigos atingido", MaxAmigos)
+	}
+	if resposta.Mensagem != "" {
+		muAmigos.Unlock()
+		resposta.Tipo = "Erro"
+		enviarResposta(conn, resposta)
+		return
+	}
+	if pedidosAmizade[id][destinatario] {
+		muAmigos.Unlock()
+		responderAmizade(conn, id, destinatario, true)
+		return
+	}
+	if pedidosAmizade[destinatario] == nil {
+		pedidosAmizade[destinatario] = make(map[string]bool)
+	}
+	pedidosAmizade[destinatario][id] = true
+	muAmigos.Unlock()
+
+	resposta.Tipo = "Pedido_Amizade"
+	resposta.Mensagem = fmt.Sprintf("Jogador %s quer ser seu amigo, responda com Aceitar_Amigo %s ou Recusar_Amigo %s", descreverJogador(id), id, id)
+	enviarParaJogadores(resposta, destinatario)
+	enviarResposta(conn, Resposta{Tipo: "Amizade", Mensagem: fmt.Sprintf("Pedido de amizade enviado ao jogador %s", destinatario)})
+
+	//Log do servidor
+	color.Cyan("Jogador %s pediu amizade a %s", id, destinatario)
+}
+
+// Função para aceitar ou recusar o pedido de amizade recebido
+func responderAmizade(conn net.Conn, id, remetente string, aceitar bool) {
+	remetente = strings.TrimSpace(remetente)
+	presencaId, presencaRemetente := presencaDe(id), presencaDe(remetente)
+
+	muAmigos.Lock()
+	if !pedidosAmizade[id][remetente] {
+		muAmigos.Unlock()
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: fmt.Sprintf("Nenhum pedido de amizade do jogador %s", remetente)})
+		return
+	}
+	delete(pedidosAmizade[id], remetente)
+	if aceitar {
+		for _, par := range [][2]string{{id, remetente}, {remetente, id}} {
+			if amigos[par[0]] == nil {
+				amigos[par[0]] = make(map[string]bool)
+			}
+			amigos[par[0]][par[1]] = true
+		}
+		//Presença enviada junto com a amizade, os avisos seguintes partem dela
+		ultimaPresenca[id] = presencaId
+		ultimaPresenca[remetente] = presencaRemetente
+	}
+	muAmigos.Unlock()
+
+	if !aceitar {
+		enviarResposta(conn, Resposta{Tipo: "Amizade", Mensagem: fmt.Sprintf("Pedido de amizade do jogador %s recusado", remetente)})
+		return
+	}
+
+	//Cada lado recebe a presença atual do novo amigo
+	enviarResposta(conn, Resposta{Tipo: "Amizade", Mensagem: fmt.Sprintf("Agora você é amigo do jogador %s", remetente), Amigos: []Amigo{descreverAmigo(remetente, false)}})
+	resposta := Resposta{Tipo: "Amizade", Mensagem: fmt.Sprintf("Jogador %s aceitou seu pedido de amizade", id), Amigos: []Amigo{descreverAmigo(id, false)}}
+	enviarParaJogadores(resposta, remetente)
+
+	//Log do servidor
+	color.Cyan("Jogadores %s e %s agora são amigos", id, remetente)
+}
+
+// Função para desfazer a amizade nos dois lados, retorna false se não eram amigos
+func desfazerAmizade(id, outro string) bool {
+	muAmigos.Lock()
+	defer muAmigos.Unlock()
+	if !amigos[id][outro] {
+		return false
+	}
+	delete(amigos[id], outro)
+	delete(amigos[outro], id)
+	return true
+}
+
+// Função para remover um amigo da lista
+func removerAmigo(conn net.Conn, id, alvo string) {
+	alvo = strings.TrimSpace(alvo)
+	if !desfazerAmizade(id, alvo) {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: fmt.Sprintf("Jogador %s não é seu amigo", alvo)})
+		return
+	}
+	enviarResposta(conn, Resposta{Tipo: "Amizade", Mensagem: fmt.Sprintf("Jogador %s removido dos amigos", alvo)})
+}
+
+// Função para enviar os amigos com a presença atual e os pedidos de amizade recebidos
+func listarAmigos(conn net.Conn, id string) {
+	muAmigos.Lock()
+	ids := make([]string, 0, len(amigos[id]))
+	for amigo := range amigos[id] {
+		ids = append(ids, amigo)
+	}
+	pedidos := make([]string, 0, len(pedidosAmizade[id]))
+	for remetente := range pedidosAmizade[id] {
+		pedidos = append(pedidos, remetente)
+	}
+	muAmigos.Unlock()
+
+	sort.Strings(ids)
+	sort.Strings(pedidos)
+	lista := make([]Amigo, 0, len(ids)+len(pedidos))
+	for _, amigo := range ids {
+		lista = append(lista, descreverAmigo(amigo, false))
+	}
+	for _, remetente := range pedidos {
+		lista = append(lista, descreverAmigo(remetente, true))
+	}
+	enviarResposta(conn, Resposta{Tipo: "Lista_Amigos", Amigos: lista})
+}
+
+// Função da goroutine que confere a presença dos jogadores com amigos e avisa os amigos das mudanças.
+// A presença é derivada do estado do servidor, então as mudanças são percebidas comparando com o último aviso.
+// As amizades continuam enquanto a sessão espera a reconexão, os amigos recebem a mudança para Offline por aqui
+func vigiarPresencas() {
+	ticker := time.NewTicker(IntervaloPresenca)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		muAmigos.Lock()
+		ids := make([]string, 0, len(amigos))
+		for id, lista := range amigos {
+			if len(lista) > 0 {
+				ids = append(ids, id)
+			}
+		}
+		muAmigos.Unlock()
+
+		for _, id := range ids {
+			presenca := presencaDe(id)
+
+			muAmigos.Lock()
+			anterior := ultimaPresenca[id]
+			ultimaPresenca[id] = presenca
+			destinatarios := make([]string, 0, len(amigos[id]))
+			for amigo := range amigos[id] {
+				destinatarios = append(destinatarios, amigo)
+			}
+			muAmigos.Unlock()
+
+			if anterior == presenca {
+				continue
+			}
+			resposta := Resposta{
+				Tipo:     "Presenca",
+				Mensagem: fmt.Sprintf("Amigo %s agora está %s", descreverJogador(id), presenca),
+				Amigos:   []Amigo{{Id: id, Apelido: apelidoDe(id), Presenca: presenca}},
+			}
+			enviarParaJogadores(resposta, destinatarios...)
+		}
+	}
+}
+
+// Função para desfazer as amizades e pedidos do jogador cuja sessão acabou.
+// Os ids não são reutilizados, então o jogador nunca volta a ficar online com esse id
+func limparAmizades(id string) {
+	muAmigos.Lock()
+	destinatarios := make([]string, 0, len(amigos[id]))
+	for amigo := range amigos[id] {
+		destinatarios = append(destinatarios, amigo)
+		delete(amigos[amigo], id)
+	}
+	delete(amigos, id)
+	delete(pedidosAmizade, id)
+	for _, pedidos := range pedidosAmizade {
+		delete(pedidos, id)
+	}
+	delete(ultimaPresenca, id)
+	muAmigos.Unlock()
+
+	resposta := Resposta{Tipo: "Presenca", Mensagem: fmt.Sprintf("Amigo %s saiu do jogo e foi removido da lista", id)}
+	enviarParaJogadores(resposta, destinatarios...)
+}
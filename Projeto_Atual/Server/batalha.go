@@ -0,0 +1,639 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Constantes dos modos de batalha
+const (
+	ModoDuelo            = iota //1 contra 1
+	ModoEquipes                 //2 contra 2 com ordem de turnos compartilhada
+	ModoTodosContraTodos        //3 a 4 jogadores, cada atacante escolhe o alvo
+)
+
+// Participante de uma batalha
+type Participante struct {
+	Id          string
+	Equipe      int
+	Deck        []Tanque //Cartas ainda disponíveis
+	DeckInicial []Tanque //Deck no início da batalha, guardado no histórico
+	Usadas      []string //Modelos colocados em campo, na ordem
+	Carta       *Tanque  //Tanque em campo
+	Canal       chan int //Índice da carta escolhida
+	CanalAlvo   chan int //Índice do alvo escolhido
+	Dano        int      //Dano total causado
+	Eliminado   bool
+}
+
+// Struct para dados de uma batalha
+type Batalha struct {
+	Id               int
+	Modo             int
+	Participantes    []*Participante //Na ordem dos turnos
+	Encerramento     chan bool
+	Fim              chan struct{} //Fechado ao encerrar a batalha, os canais de escolha nunca são fechados
+	EncerramentoOnce sync.Once
+	Regras           Regras
+	Vencedores       []string //Preenchido ao encerrar a batalha
+	Motivo           string   //Preenchido ao encerrar a batalha
+	EmSerie          bool     //Resultado contado apenas no fim da série
+	ContraIA         bool     //Batalha contra o bot do servidor, fora do ranking e sem moedas
+	Desconectados    []string //Jogadores que desconectaram durante a batalha
+	Inicio           time.Time
+	Turnos           int            //Turnos realizados
+	Semente          int64          //Semente do sorteio dos decks, guardada no replay
+	Eventos          []EventoReplay //Eventos públicos da batalha, guardados no replay
+	Espectadores     []string       //Protegido por muEspectadores, assim como os campos abaixo
+	Campo            []Tanque       //Cartas em campo no último turno
+	UltimoTurno      string         //Mensagem do último turno
+	Encerrada        bool
+}
+
+// Contador do ID das batalhas, protegido por muBatalhas
+var batalhaCounter int
+
+// Função para verificar se o jogador pode iniciar um duelo contra o oponente
+func validarOponente(id, idOponente string) error {
+	if id == idOponente || !mesmoGrupo(id, idOponente) {
+		return errors.New("Você só pode batalhar com um jogador do seu grupo")
+	}
+	if emBatalha(id) || emBatalha(idOponente) {
+		return errors.New("Já existe uma batalha em andamento")
+	}
+	return nil
+}
+
+// Função para verificar se algum jogador já está em batalha ou em outra série.
+// Deve ser chamada com muBatalhas bloqueado
+func verificarOcupados(ids []string, serie *Serie) error {
+	muSeries.RLock()
+	defer muSeries.RUnlock()
+	for _, id := range ids {
+		if _, existe := batalhas[id]; existe {
+			return fmt.Errorf("Jogador %s já está em uma batalha", id)
+		}
+		if emSerie, existe := series[id]; existe && emSerie != serie {
+			return fmt.Errorf("Jogador %s já está em uma série", id)
+		}
+	}
+	return nil
+}
+
+// Função para verificar se o jogador está em uma batalha ou série em andamento
+func emBatalha(id string) bool {
+	muBatalhas.RLock()
+	_, existe := batalhas[id]
+	muBatalhas.RUnlock()
+
+	muSeries.RLock()
+	_, emSerie := series[id]
+	muSeries.RUnlock()
+
+	return existe || emSerie
+}
+
+// Função para criar uma batalha e registrar no map de batalhas.
+// Os ids estão na ordem dos turnos, no modo de equipes as posições pares formam uma equipe e as ímpares a outra.
+// Os jogadores sem deck em decks usam o deck selecionado ou cartas da própria coleção.
+// A batalha de uma série recebe a série, que não conta como batalha em andamento para os seus jogadores
+func novaBatalha(modo int, ids []string, regras Regras, decks map[string][]Tanque, serie *Serie) (*Batalha, error) {
+	return criarBatalha(modo, ids, regras, decks, serie, nil)
+}
+
+// Função para criar a batalha conferindo cada jogador com disponivel no mesmo bloqueio que registra a batalha
+func criarBatalha(modo int, ids []string, regras Regras, decks map[string][]Tanque, serie *Serie, disponivel func(id string) error) (*Batalha, error) {
+	batalha := &Batalha{
+		Modo:         modo,
+		EmSerie:      serie != nil,
+		Encerramento: make(chan bool),
+		Fim:          make(chan struct{}),
+		Regras:       regras,
+		Semente:      time.Now().UnixNano(),
+	}
+
+	//Gerador aleatório da batalha, a mesma semente e coleções geram os mesmos decks
+	r := rand.New(rand.NewSource(batalha.Semente))
+
+	for i, id := range ids {
+		equipe := i
+		if modo == ModoEquipes {
+			equipe = i % 2
+		}
+		deck, fornecido := decks[id]
+		if !fornecido {
+			var err error
+			if deck, err = montarDeckBatalha(id, regras.TamanhoDeck, r); err != nil {
+				return nil, err
+			}
+		}
+		batalha.Participantes = append(batalha.Participantes, &Participante{
+			Id:          id,
+			Equipe:      equipe,
+			Deck:        deck,
+			DeckInicial: append([]Tanque(nil), deck...),
+			Canal:       make(chan int),
+			CanalAlvo:   make(chan int),
+		})
+	}
+
+	//Conferir e registrar no mesmo bloqueio, pedidos simultâneos não criam duas batalhas para o mesmo jogador
+	muBatalhas.Lock()
+	err := verificarOcupados(ids, serie)
+	for i := 0; err == nil && disponivel != nil && i < len(ids); i++ {
+		err = disponivel(ids[i])
+	}
+	if err != nil {
+		muBatalhas.Unlock()
+		return nil, err
+	}
+	batalhaCounter++
+	batalha.Id = batalhaCounter
+	for _, id := range ids {
+		batalhas[id] = batalha
+	}
+	muBatalhas.Unlock()
+
+	//Participantes que estavam assistindo outra batalha deixam de assistir
+	for _, id := range ids {
+		pararDeAssistir(id, true)
+	}
+
+	return batalha, nil
+}
+
+// Função para realizar partida/batalha entre jogadores
+func realizarBatalha(batalha *Batalha) {
+	ids := batalha.ids()
+	color.Yellow("Iniciando batalha entre %s", strings.Join(ids, ", "))
+	batalha.Inicio = time.Now()
+
+	//Envio das regras da batalha para todos os jogadores
+	respostaRegras := Resposta{Tipo: "Regras_Batalha", Mensagem: batalha.Regras.descricao()}
+	enviarParaJogadores(respostaRegras, ids...)
+
+	//Envio de início de batalha para cada jogador junto com os oponentes e o seu deck
+	for _, p := range batalha.Participantes {
+		respostaInicial := Resposta{Tipo: "Inicio_Batalha", Mensagem: strings.Join(batalha.oponentes(p), ", "), Cartas: p.Deck}
+		enviarParaJogadores(respostaInicial, p.Id)
+	}
+
+	time.Sleep(batalha.Regras.Ritmo)
+
+	//Estado inicial de partida
+	turno := 0
+	vez := 0 //Posição do próximo atacante na ordem dos turnos
+	motivo := ""
+
+	for {
+		select {
+		//Canal para caso ocorra desconexão de um jogador
+		case <-batalha.Encerramento:
+			color.Red("Batalha encerrada à força!")
+			encerrarBatalha(batalha, nil, "Desconexão/força")
+			return
+		default:
+		}
+
+		//Batalha pausada enquanto algum participante estiver esperando reconexão
+		batalha.aguardarAusentes()
+
+		//Verificar se cada participante tem carta viva, pedindo uma nova se necessário
+		for _, p := range batalha.Participantes {
+			if p.Eliminado || p.Carta != nil {
+				continue
+			}
+
+			if batalha.desconectou(p.Id) {
+				p.Eliminado = true
+				motivo = "Desconexão"
+			} else if len(p.Deck) == 0 { //Participante perde por usar todas as cartas do deck
+				p.Eliminado = true
+				motivo = "Sem cartas restantes do oponente"
+			} else if novaCarta, ok := batalha.pedirCarta(p); !ok {
+				p.Eliminado = true
+				motivo = "Timeout"
+				if batalha.desconectou(p.Id) {
+					motivo = "Desconexão"
+				}
+			} else {
+				p.Carta = novaCarta
+				p.Usadas = append(p.Usadas, novaCarta.Modelo)
+			}
+
+			//Batalha termina quando sobra apenas uma equipe
+			if equipes := batalha.equipesVivas(); len(equipes) <= 1 {
+				encerrarBatalha(batalha, batalha.membrosEquipes(equipes), motivo)
+				return
+			}
+		}
+
+		atacante := batalha.proximoAtacante(&vez)
+		alvo := escolherAlvo(batalha, atacante)
+
+		dano := min(atacante.Carta.Ataque, alvo.Carta.Vida)
+		atacante.Dano += dano
+		registrarDanoMissao(atacante.Id, *atacante.Carta, dano)
+		alvo.Carta.Vida -= atacante.Carta.Ataque
+
+		respostaTurno := Resposta{
+			Tipo:     "Turno_Realizado",
+			Mensagem: fmt.Sprintf("Jogador %s atacou o jogador %s no turno %d", atacante.Id, alvo.Id, turno),
+			Cartas:   batalha.cartasEmCampo(),
+		}
+		batalha.registrarEvento(respostaTurno)
+		batalha.transmitir(respostaTurno)
+
+		//Verificar se vida da carta atacada foi reduzida a zero ou menos
+		if alvo.Carta.Vida <= 0 {
+			alvo.Carta = nil
+		}
+
+		turno++
+		batalha.Turnos = turno
+
+		//Verificar fim da batalha por dano total após o limite de turnos
+		if batalha.Regras.Vitoria == VitoriaDanoTotal && turno >= batalha.Regras.LimiteTurnos {
+			encerrarPorDano(batalha)
+			return
+		}
+
+		time.Sleep(batalha.Regras.Ritmo)
+	}
+}
+
+// Função para encerrar a batalha dando a vitória à equipe com maior dano total
+func encerrarPorDano(batalha *Batalha) {
+	danos := make(map[int]int)
+	equipes := make([]int, 0)
+	for _, p := range batalha.Participantes {
+		if _, existe := danos[p.Equipe]; !existe {
+			equipes = append(equipes, p.Equipe)
+		}
+		danos[p.Equipe] += p.Dano
+	}
+
+	placar := make([]string, 0, len(equipes))
+	melhores := make([]int, 0)
+	for _, equipe := range equipes {
+		placar = append(placar, fmt.Sprintf("%d", danos[equipe]))
+		if len(melhores) == 0 || danos[equipe] > danos[melhores[0]] {
+			melhores = []int{equipe}
+		} else if danos[equipe] == danos[melhores[0]] {
+			melhores = append(melhores, equipe)
+		}
+	}
+
+	motivo := "Dano total " + strings.Join(placar, " x ")
+	if len(melhores) > 1 {
+		encerrarBatalha(batalha, nil, "Empate - "+motivo)
+		return
+	}
+	encerrarBatalha(batalha, batalha.membrosEquipes(melhores), motivo)
+}
+
+// Função para pedir a próxima carta ao participante, pausando a batalha se ele cair durante a escolha
+func (b *Batalha) pedirCarta(p *Participante) (*Tanque, bool) {
+	for {
+		carta, ok := esperarCarta(p.Id, p.Canal, &p.Deck, b.Regras.TempoTurno)
+		if ok || !b.pausarSeAusente(p.Id) {
+			return carta, ok
+		}
+	}
+}
+
+// Função para pedir ao jogador uma carta do deck e esperar a escolha dentro do tempo limite.
+// Retorna sem carta também se o jogador cair durante a escolha
+func esperarCarta(id string, canal chan int, deck *[]Tanque, tempo time.Duration) (*Tanque, bool) {
+	//Um único prazo para todas as tentativas, escolhas inválidas não renovam o tempo
+	prazo := time.Now().Add(tempo)
+	timeout := time.After(tempo)
+	verificacao := time.NewTicker(IntervaloVerificacao)
+	defer verificacao.Stop()
+
+	pedir := true
+	for {
+		//Envia as cartas ainda disponíveis e o tempo restante em segundos
+		if pedir {
+			resposta := Resposta{
+				Tipo:     "Enviar_Próxima_Carta",
+				Mensagem: fmt.Sprintf("%d", int(time.Until(prazo).Seconds())),
+				Cartas:   *deck,
+			}
+			enviarParaJogadores(resposta, id)
+			pedir = false
+		}
+
+		select {
+		case indice := <-canal:
+			if indice < 0 || indice >= len(*deck) {
+				pedir = true
+				continue //Escolha fora do deck, pede novamente
+			}
+
+			//Retira a carta escolhida do deck
+			carta := (*deck)[indice]
+			*deck = append((*deck)[:indice:indice], (*deck)[indice+1:]...)
+			return &carta, true
+		case <-verificacao.C:
+			if estaAusente(id) {
+				return nil, false
+			}
+		case <-timeout:
+			return nil, false
+		}
+	}
+}
+
+// Função para receber a carta ou o alvo escolhido pelo jogador e mandar no canal do participante
+func receberEscolha(conn net.Conn, id, tipo, mensagem string) {
+	var resposta Resposta
+
+	muBatalhas.RLock()
+	batalha, existe := batalhas[id]
+	muBatalhas.RUnlock()
+
+	//Índice da carta ou do alvo escolhido dentro da lista enviada pelo servidor
+	indice, errIndice := strconv.Atoi(mensagem)
+
+	if !existe {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Você não está em uma batalha"
+		enviarResposta(conn, resposta)
+		return
+	}
+	if errIndice != nil {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Índice de carta inválido"
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	//Verifica qual participante é para mandar no canal correto
+	participante := batalha.participante(id)
+	canal := participante.Canal
+	if tipo == "Alvo" {
+		canal = participante.CanalAlvo
+	}
+
+	//Espera curta, a batalha pode estar verificando a ausência do jogador no instante do envio
+	select {
+	case canal <- indice:
+		//Apenas envia
+	case <-batalha.Fim:
+		//Batalha encerrada, a escolha é descartada
+	case <-time.After(IntervaloVerificacao):
+		color.Red("Canal cheio ou encerrado para %s", participante.Id)
+		//Alvo fora de hora é ignorado, o servidor já escolheu um alvo padrão
+		if tipo == "Próxima_Carta" {
+			select {
+			case batalha.Encerramento <- true:
+			case <-batalha.Fim:
+			default:
+			}
+		}
+	}
+}
+
+// Função para escolher o participante atacado pelo atacante.
+// No modo todos contra todos o atacante escolhe, nos outros é o próximo oponente na ordem dos turnos
+func escolherAlvo(batalha *Batalha, atacante *Participante) *Participante {
+	alvos := batalha.alvosVivos(atacante)
+	if batalha.Modo != ModoTodosContraTodos || len(alvos) == 1 {
+		return alvos[0]
+	}
+
+	cartas := make([]Tanque, 0, len(alvos))
+	for _, alvo := range alvos {
+		cartas = append(cartas, *alvo.Carta)
+	}
+
+	resposta := Resposta{
+		Tipo:     "Escolher_Alvo",
+		Mensagem: fmt.Sprintf("%d", int(batalha.Regras.TempoTurno.Seconds())),
+		Cartas:   cartas,
+	}
+	enviarParaJogadores(resposta, atacante.Id)
+
+	//Sem escolha válida no tempo limite o primeiro alvo é atacado
+	select {
+	case indice := <-atacante.CanalAlvo:
+		if indice >= 0 && indice < len(alvos) {
+			return alvos[indice]
+		}
+	case <-time.After(batalha.Regras.TempoTurno):
+	}
+	return alvos[0]
+}
+
+// Função para pegar o próximo participante não eliminado na ordem dos turnos
+func (b *Batalha) proximoAtacante(vez *int) *Participante {
+	n := len(b.Participantes)
+	for i := 0; i < n; i++ {
+		p := b.Participantes[(*vez+i)%n]
+		if !p.Eliminado {
+			*vez = (*vez + i + 1) % n
+			return p
+		}
+	}
+	return nil
+}
+
+// Função para listar os oponentes vivos do atacante, começando pelo próximo na ordem dos turnos
+func (b *Batalha) alvosVivos(atacante *Participante) []*Participante {
+	inicio := 0
+	for i, p := range b.Participantes {
+		if p == atacante {
+			inicio = i
+		}
+	}
+
+	alvos := make([]*Participante, 0)
+	n := len(b.Participantes)
+	for i := 1; i < n; i++ {
+		p := b.Participantes[(inicio+i)%n]
+		if !p.Eliminado && p.Equipe != atacante.Equipe {
+			alvos = append(alvos, p)
+		}
+	}
+	return alvos
+}
+
+// Função para listar as equipes que ainda têm participantes não eliminados
+func (b *Batalha) equipesVivas() []int {
+	vistas := make(map[int]bool)
+	equipes := make([]int, 0)
+	for _, p := range b.Participantes {
+		if !p.Eliminado && !vistas[p.Equipe] {
+			vistas[p.Equipe] = true
+			equipes = append(equipes, p.Equipe)
+		}
+	}
+	return equipes
+}
+
+// Função para listar os ids dos participantes das equipes informadas
+func (b *Batalha) membrosEquipes(equipes []int) []string {
+	membros := make([]string, 0)
+	for _, p := range b.Participantes {
+		for _, equipe := range equipes {
+			if p.Equipe == equipe {
+				membros = append(membros, p.Id)
+			}
+		}
+	}
+	return membros
+}
+
+// Função para listar os ids dos oponentes de um participante
+func (b *Batalha) oponentes(participante *Participante) []string {
+	ids := make([]string, 0)
+	for _, p := range b.Participantes {
+		if p.Equipe != participante.Equipe {
+			ids = append(ids, p.Id)
+		}
+	}
+	return ids
+}
+
+// Função para listar as cartas em campo na ordem dos turnos
+func (b *Batalha) cartasEmCampo() []Tanque {
+	cartas := make([]Tanque, 0, len(b.Participantes))
+	for _, p := range b.Participantes {
+		if p.Carta != nil {
+			cartas = append(cartas, *p.Carta)
+		}
+	}
+	return cartas
+}
+
+// Função para listar os ids de todos os participantes
+func (b *Batalha) ids() []string {
+	ids := make([]string, 0, len(b.Participantes))
+	for _, p := range b.Participantes {
+		ids = append(ids, p.Id)
+	}
+	return ids
+}
+
+// Função para encontrar o participante pelo id do jogador
+func (b *Batalha) participante(id string) *Participante {
+	for _, p := range b.Participantes {
+		if p.Id == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// Função para verificar se o jogador desconectou durante a batalha
+func (b *Batalha) desconectou(id string) bool {
+	muBatalhas.RLock()
+	defer muBatalhas.RUnlock()
+	for _, desconectado := range b.Desconectados {
+		if desconectado == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Função para verificar se o jogador está entre os vencedores da batalha
+func (b *Batalha) venceu(id string) bool {
+	for _, vencedor := range b.Vencedores {
+		if vencedor == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Função centralizada para finalizar corretamente uma batalha(fechar canais e atualizar map)
+func encerrarBatalha(batalha *Batalha, vencedores []string, motivo string) {
+	ids := batalha.ids()
+
+	//Remover batalha do map
+	muBatalhas.Lock()
+	for _, id := range ids {
+		delete(batalhas, id)
+	}
+	desconectados := append([]string(nil), batalha.Desconectados...)
+	muBatalhas.Unlock()
+
+	//Guardar resultado para quem esperou a batalha terminar
+	batalha.Vencedores = vencedores
+	batalha.Motivo = motivo
+
+	//Atualizar ranking e histórico antes de avisar os jogadores
+	registrarResultadoBatalha(batalha, desconectados)
+	idPartida := registrarPartida(batalha)
+
+	//Notificar para as conexões existentes a mensagem e fim de partida
+	var resposta Resposta
+	resposta.Tipo = "Fim_Batalha"
+	if len(vencedores) > 1 {
+		resposta.Mensagem = fmt.Sprintf("Batalha encerrada! Jogadores %s venceram (%s).", strings.Join(vencedores, " e "), motivo)
+	} else {
+		resposta.Mensagem = fmt.Sprintf("Batalha encerrada! Jogador %s venceu (%s).", nomeVencedor(vencedores), motivo)
+	}
+	batalha.registrarEvento(resposta)
+	salvarReplay(batalha, idPartida)
+	batalha.transmitir(resposta)
+	premiarVencedores(batalha)
+	ganharExperiencia(batalha)
+	registrarBatalhaMissoes(batalha)
+
+	//Sinaliza o fim sem fechar os canais de escolha, que ainda podem receber envios de receberEscolha
+	batalha.EncerramentoOnce.Do(func() {
+		close(batalha.Fim)
+	})
+
+	//Log do servidor
+	color.Yellow("Batalha finalizada entre %s, vencedor %s", strings.Join(ids, ", "), nomeVencedor(vencedores))
+}
+
+// Função para descrever os vencedores em texto, "Ninguém" se não houver
+func nomeVencedor(vencedores []string) string {
+	if len(vencedores) == 0 {
+		return "Ninguém"
+	}
+	return strings.Join(vencedores, " e ")
+}
+
+// Função para montar o deck de batalha com o deck selecionado ou sorteando cartas da coleção do jogador.
+// Retorna erro se o jogador não tem cartas suficientes para o tamanho do deck
+func montarDeckBatalha(id string, tamanho int, r *rand.Rand) ([]Tanque, error) {
+	if deck, ok := deckSelecionadoValido(id, tamanho); ok {
+		return deck, nil
+	}
+
+	muColecoes.RLock()
+	defer muColecoes.RUnlock()
+	colecao := colecoes[id]
+	if len(colecao) < tamanho {
+		return nil, fmt.Errorf("Jogador %s tem %d cartas e a batalha precisa de um deck com %d", id, len(colecao), tamanho)
+	}
+	deck := make([]Tanque, 0, tamanho)
+	for _, i := range r.Perm(len(colecao))[:tamanho] {
+		deck = append(deck, colecao[i])
+	}
+	return deck, nil
+}
+
+// Função para verificar se o jogador consegue montar um deck do tamanho pedido
+func deckPronto(id string, tamanho int) bool {
+	if _, ok := deckSelecionadoValido(id, tamanho); ok {
+		return true
+	}
+	muColecoes.RLock()
+	defer muColecoes.RUnlock()
+	return len(colecoes[id]) >= tamanho
+}
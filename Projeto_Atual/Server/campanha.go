@@ -0,0 +1,349 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Missão da campanha carregada de um arquivo json do diretório da campanha
+type MissaoCampanha struct {
+	Numero        int      `json:"numero"`
+	Nome          string   `json:"nome"`
+	Descricao     string   `json:"descricao"`
+	Regras        string   `json:"regras"`         //Nome das regras de batalha, vazio usa as padrão
+	Dificuldade   string   `json:"dificuldade"`    //Estratégia do bot inimigo
+	Inimigos      []string `json:"inimigos"`       //Modelos do deck inimigo, na ordem do deck
+	VidaInimiga   int      `json:"vida_inimiga"`   //Vida inicial dos inimigos em porcentagem, 0 mantém a do catálogo
+	AtaqueInimigo int      `json:"ataque_inimigo"` //Ataque dos inimigos em porcentagem, 0 mantém o do catálogo
+	Classes       []string `json:"classes"`        //Classes permitidas no deck do jogador, vazio permite todas
+	Moedas        int      `json:"moedas"`         //Recompensa da primeira vitória
+	Pacote        string   `json:"pacote"`
+	Modelo        string   `json:"modelo"`
+}
+
+// Situação de uma missão da campanha para o jogador, enviada ao cliente
+type EtapaCampanha struct {
+	Numero     int    `json:"numero"`
+	Nome       string `json:"nome"`
+	Descricao  string `json:"descricao"`
+	Especiais  string `json:"especiais"`
+	Recompensa string `json:"recompensa"`
+	Liberada   bool   `json:"liberada"`
+	Concluida  bool   `json:"concluida"`
+}
+
+// Variáveis da campanha
+var (
+	campanha         []MissaoCampanha        //Missões na ordem, carregadas na inicialização
+	progressoMissoes = make(map[string]int)  //Última missão concluída por jogador
+	jogandoCampanha  = make(map[string]bool) //Jogadores com uma missão em andamento
+	muCampanha       sync.Mutex              //Mutex para sincronizar o progresso da campanha
+)
+
+// Diretório padrão dos arquivos da campanha, pode ser trocado pela flag -campanha
+const DiretorioCampanha = "campanha"
+
+// Função para carregar as missões dos arquivos json do diretório. Missões inválidas são ignoradas
+func carregarCampanha(diretorio string) {
+	arquivos, err := filepath.Glob(filepath.Join(diretorio, "*.json"))
+	if err != nil || len(arquivos) == 0 {
+		color.Red("Nenhuma missão de campanha encontrada em %s", diretorio)
+		return
+	}
+
+	missoes := make([]MissaoCampanha, 0, len(arquivos))
+	for _, arquivo := range arquivos {
+		dados, err := os.ReadFile(arquivo)
+		var missao MissaoCampanha
+		if err == nil {
+			err = json.Unmarshal(dados, &missao)
+		}
+		if err == nil {
+			err = validarMissao(missao)
+		}
+		if err != nil {
+			color.Red("Missão %s ignorada: %v", arquivo, err)
+			continue
+		}
+		missoes = append(missoes, missao)
+	}
+
+	//Missões numeradas em sequência a partir de 1, sem buracos
+	sort.Slice(missoes, func(i, j int) bool {
+		return missoes[i].Numero < missoes[j].Numero
+	})
+	for i, missao := range missoes {
+		if missao.Numero != i+1 {
+			color.Red("Campanha interrompida na missão %d, esperada a missão %d", missao.Numero, i+1)
+			missoes = missoes[:i]
+			break
+		}
+	}
+
+	campanha = missoes
+	color.Green("Campanha carregada com %d missões", len(campanha))
+}
+
+// Função para validar os modelos, regras e dificuldade da missão
+func validarMissao(missao MissaoCampanha) error {
+	if missao.Numero < 1 || missao.Nome == "" {
+		return fmt.Errorf("número ou nome ausente")
+	}
+	if _, ok := buscarRegras(missao.Regras); !ok {
+		return fmt.Errorf("regras %s não existem", missao.Regras)
+	}
+	if _, ok := buscarDificuldade(missao.Dificuldade); !ok {
+		return fmt.Errorf("dificuldade %s não existe", missao.Dificuldade)
+	}
+	if len(missao.Inimigos) == 0 {
+		return fmt.Errorf("deck inimigo vazio")
+	}
+	for _, modelo := range append(append([]string(nil), missao.Inimigos...), missao.Modelo) {
+		if _, existe := buscarModelo(modelo); !existe && modelo != "" {
+			return fmt.Errorf("modelo %s não existe", modelo)
+		}
+	}
+	if _, existe := buscarTipoPacote(missao.Pacote); !existe && missao.Pacote != "" {
+		return fmt.Errorf("pacote %s não existe", missao.Pacote)
+	}
+	return nil
+}
+
+// Função para descrever as regras especiais da missão
+func descreverEspeciais(missao MissaoCampanha) string {
+	especiais := make([]string, 0)
+	if missao.VidaInimiga > 0 && missao.VidaInimiga != 100 {
+		especiais = append(especiais, fmt.Sprintf("inimigos começam com %d%% da vida", missao.VidaInimiga))
+	}
+	if missao.AtaqueInimigo > 0 && missao.AtaqueInimigo != 100 {
+		especiais = append(especiais, fmt.Sprintf("inimigos com %d%% do ataque", missao.AtaqueInimigo))
+	}
+	if len(missao.Classes) > 0 {
+		especiais = append(especiais, "apenas tanques "+strings.Join(missao.Classes, ", "))
+	}
+	if len(especiais) == 0 {
+		return "nenhuma"
+	}
+	return strings.Join(especiais, "; ")
+}
+
+// Função para descrever a recompensa da primeira vitória na missão
+func descreverRecompensaMissao(missao MissaoCampanha) string {
+	recompensas := make([]string, 0)
+	if missao.Moedas > 0 {
+		recompensas = append(recompensas, fmt.Sprintf("%d moedas", missao.Moedas))
+	}
+	if missao.Pacote != "" {
+		recompensas = append(recompensas, "pacote "+missao.Pacote)
+	}
+	if missao.Modelo != "" {
+		recompensas = append(recompensas, missao.Modelo)
+	}
+	return strings.Join(recompensas, ", ")
+}
+
+// Função para enviar as missões da campanha com a situação do jogador
+func enviarCampanha(conn net.Conn, id string) {
+	muCampanha.Lock()
+	concluidas := progressoMissoes[id]
+	muCampanha.Unlock()
+
+	etapas := make([]EtapaCampanha, 0, len(campanha))
+	for _, missao := range campanha {
+		etapas = append(etapas, EtapaCampanha{
+			Numero:     missao.Numero,
+			Nome:       missao.Nome,
+			Descricao:  missao.Descricao,
+			Especiais:  descreverEspeciais(missao),
+			Recompensa: descreverRecompensaMissao(missao),
+			Liberada:   missao.Numero <= concluidas+1,
+			Concluida:  missao.Numero <= concluidas,
+		})
+	}
+	mensagem := fmt.Sprintf("Campanha: %d de %d missões concluídas", concluidas, len(campanha))
+	enviarResposta(conn, Resposta{Tipo: "Campanha", Mensagem: mensagem, Campanha: etapas})
+}
+
+// Função para montar o deck inimigo fixo da missão com as regras especiais aplicadas
+func montarDeckInimigo(missao MissaoCampanha) []Tanque {
+	deck := make([]Tanque, 0, len(missao.Inimigos))
+	for _, nome := range missao.Inimigos {
+		modelo, _ := buscarModelo(nome)
+		carta := Tanque{Modelo: modelo.Modelo, Vida: modelo.Vida, Ataque: modelo.Ataque}
+		if missao.VidaInimiga > 0 {
+			carta.Vida = max(carta.Vida*missao.VidaInimiga/100, 1)
+		}
+		if missao.AtaqueInimigo > 0 {
+			carta.Ataque = max(carta.Ataque*missao.AtaqueInimigo/100, 1)
+		}
+		deck = append(deck, carta)
+	}
+	return deck
+}
+
+// Função para montar o deck do jogador respeitando as classes permitidas na missão.
+// Usa o deck selecionado se todas as cartas forem permitidas, senão sorteia da coleção
+func montarDeckMissao(id string, missao MissaoCampanha, tamanho int) ([]Tanque, error) {
+	if len(missao.Classes) == 0 {
+		return nil, nil
+	}
+	permitida := func(carta Tanque) bool {
+		for _, classe := range missao.Classes {
+			if strings.HasSuffix(carta.Modelo, classe) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if deck, ok := deckSelecionadoValido(id, tamanho); ok {
+		todasPermitidas := true
+		for _, carta := range deck {
+			todasPermitidas = todasPermitidas && permitida(carta)
+		}
+		if todasPermitidas {
+			return deck, nil
+		}
+	}
+
+	muColecoes.RLock()
+	permitidas := make([]Tanque, 0)
+	for _, carta := range colecoes[id] {
+		if permitida(carta) {
+			permitidas = append(permitidas, carta)
+		}
+	}
+	muColecoes.RUnlock()
+
+	if len(permitidas) < tamanho {
+		return nil, fmt.Errorf("A missão %d só aceita tanques %s e você tem %d de %d necessários", missao.Numero, strings.Join(missao.Classes, ", "), len(permitidas), tamanho)
+	}
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	deck := make([]Tanque, 0, tamanho)
+	for _, i := range r.Perm(len(permitidas))[:tamanho] {
+		deck = append(deck, permitidas[i])
+	}
+	return deck, nil
+}
+
+// Função para iniciar a missão da campanha com o número da mensagem
+func iniciarMissao(conn net.Conn, id, mensagem string) {
+	var resposta Resposta
+
+	numero, err := strconv.Atoi(strings.TrimSpace(mensagem))
+	if err != nil || numero < 1 || numero > len(campanha) {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Missão %s não existe, a campanha tem %d missões", strings.TrimSpace(mensagem), len(campanha))
+		enviarResposta(conn, resposta)
+		return
+	}
+	missao := campanha[numero-1]
+	regras, _ := buscarRegras(missao.Regras)
+	dificuldade, _ := buscarDificuldade(missao.Dificuldade)
+
+	deckJogador, err := montarDeckMissao(id, missao, regras.TamanhoDeck)
+	if err == nil && emBatalha(id) {
+		err = fmt.Errorf("Já existe uma batalha em andamento")
+	}
+
+	//Conferir o progresso e marcar a missão em andamento de uma vez
+	muCampanha.Lock()
+	concluidas := progressoMissoes[id]
+	if err == nil && numero > concluidas+1 {
+		err = fmt.Errorf("Conclua a missão %d antes da missão %d", concluidas+1, numero)
+	} else if err == nil && jogandoCampanha[id] {
+		err = fmt.Errorf("Já existe uma batalha em andamento")
+	}
+	if err != nil {
+		muCampanha.Unlock()
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+	jogandoCampanha[id] = true
+	muCampanha.Unlock()
+
+	batalha, err := novaBatalhaIA(id, regras, montarDeckInimigo(missao), deckJogador)
+	if err != nil {
+		muCampanha.Lock()
+		delete(jogandoCampanha, id)
+		muCampanha.Unlock()
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	resposta.Tipo = "Missao_Campanha"
+	resposta.Mensagem = fmt.Sprintf("Missão %d: %s. %s Regras especiais: %s", missao.Numero, missao.Nome, missao.Descricao, descreverEspeciais(missao))
+	enviarResposta(conn, resposta)
+
+	go func() {
+		realizarBatalhaIA(batalha, dificuldade)
+
+		muCampanha.Lock()
+		delete(jogandoCampanha, id)
+		primeiraVitoria := batalha.venceu(id) && progressoMissoes[id] < missao.Numero
+		if primeiraVitoria {
+			progressoMissoes[id] = missao.Numero
+		}
+		muCampanha.Unlock()
+
+		if !batalha.venceu(id) {
+			enviarParaJogadores(Resposta{Tipo: "Missao_Campanha", Mensagem: fmt.Sprintf("Missão %d (%s) falhou, tente novamente", missao.Numero, missao.Nome)}, id)
+			return
+		}
+		if !primeiraVitoria {
+			enviarParaJogadores(Resposta{Tipo: "Missao_Campanha", Mensagem: fmt.Sprintf("Missão %d (%s) concluída novamente, a recompensa é dada só na primeira vez", missao.Numero, missao.Nome)}, id)
+			return
+		}
+		pagarRecompensaMissao(id, missao)
+	}()
+}
+
+// Função para pagar a recompensa da primeira vitória na missão e avisar o jogador
+func pagarRecompensaMissao(id string, missao MissaoCampanha) {
+	mensagem := fmt.Sprintf("Missão %d (%s) concluída!", missao.Numero, missao.Nome)
+	if missao.Moedas > 0 {
+		saldo := creditarMoedas(id, missao.Moedas)
+		mensagem += fmt.Sprintf(" Você ganhou %d moedas, saldo: %d moedas.", missao.Moedas, saldo)
+	}
+
+	//Cartas de recompensa: pacote fora do estoque da loja e o modelo da missão
+	cartas := make([]Tanque, 0)
+	if tipo, existe := buscarTipoPacote(missao.Pacote); existe && missao.Pacote != "" {
+		cartas = append(cartas, sortearDoPacote(tipo, id)...)
+		mensagem += fmt.Sprintf(" Você ganhou um pacote %s.", tipo.Nome)
+	}
+	if modelo, existe := buscarModelo(missao.Modelo); existe {
+		carta := cunharCarta(modelo, id, fmt.Sprintf("Recompensa da missão %d da campanha", missao.Numero))
+		cartas = append(cartas, carta)
+		mensagem += fmt.Sprintf(" Você ganhou o tanque %s.", carta.Modelo)
+	}
+	if len(cartas) > 0 {
+		muColecoes.Lock()
+		colecoes[id] = append(colecoes[id], cartas...)
+		muColecoes.Unlock()
+	}
+	if missao.Numero < len(campanha) {
+		mensagem += fmt.Sprintf(" Missão %d liberada.", missao.Numero+1)
+	} else {
+		mensagem += " Campanha completa!"
+	}
+	enviarParaJogadores(Resposta{Tipo: "Missao_Campanha", Mensagem: mensagem, Cartas: cartas}, id)
+
+	//Log do servidor
+	color.Green("Jogador %s concluiu a missão %d da campanha", id, missao.Numero)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Modelo de carta do catálogo dos pacotes. Cada cópia sorteada é cunhada como um Tanque com identidade própria
+type ModeloCarta struct {
+	Modelo     string
+	Id_jogador string
+	Vida       int
+	Ataque     int
+}
+
+// Registro de uma mudança de dono da carta
+type RegistroPosse struct {
+	Dono   string    `json:"dono"`
+	Origem string    `json:"origem"` //Como o dono conseguiu a carta, ex: "Pacote Basico", "Troca 3"
+	Data   time.Time `json:"data"`
+}
+
+// Variáveis das cartas cunhadas
+var (
+	instanciaCounter int                             //Contador para gerar o id único de cada carta
+	seriesModelo     = make(map[string]int)          //Quantidade de cartas cunhadas de cada modelo
+	procedencias     = make(map[int][]RegistroPosse) //Histórico de donos de cada carta pelo id da instância
+	muCartas         sync.Mutex                      //Mutex para sincronizar a cunhagem e a procedência
+)
+
+// Função para cunhar uma carta nova do modelo para o jogador, com id único e número de série do modelo
+func cunharCarta(modelo ModeloCarta, dono, origem string) Tanque {
+	muCartas.Lock()
+	defer muCartas.Unlock()
+
+	instanciaCounter++
+	seriesModelo[modelo.Modelo]++
+	carta := Tanque{
+		Modelo:     modelo.Modelo,
+		Id_jogador: dono,
+		Vida:       modelo.Vida,
+		Ataque:     modelo.Ataque,
+		Instancia:  instanciaCounter,
+		Serie:      seriesModelo[modelo.Modelo],
+		Estrelas:   1,
+		Nivel:      1,
+	}
+	procedencias[carta.Instancia] = []RegistroPosse{{Dono: dono, Origem: origem, Data: time.Now()}}
+	return carta
+}
+
+// Função para registrar o novo dono das cartas no histórico de cada uma
+func registrarPosse(cartas []Tanque, dono, origem string) {
+	muCartas.Lock()
+	defer muCartas.Unlock()
+
+	agora := time.Now()
+	for _, carta := range cartas {
+		if carta.Instancia == 0 {
+			continue
+		}
+		procedencias[carta.Instancia] = append(procedencias[carta.Instancia], RegistroPosse{Dono: dono, Origem: origem, Data: agora})
+	}
+}
+
+// Função para enviar o histórico de donos da carta com o id de instância informado
+func enviarProcedencia(conn net.Conn, mensagem string) {
+	instancia, err := strconv.Atoi(strings.TrimSpace(mensagem))
+
+	muCartas.Lock()
+	historico := append([]RegistroPosse(nil), procedencias[instancia]...)
+	muCartas.Unlock()
+
+	if err != nil || len(historico) == 0 {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: "Carta não encontrada"})
+		return
+	}
+	enviarResposta(conn, Resposta{Tipo: "Procedencia", Mensagem: fmt.Sprintf("%d", instancia), Procedencia: historico})
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+// Mensagem de chat com remetente, horário e canal
+type MensagemChat struct {
+	Canal        string    `json:"canal"` //"Lobby", "Grupo", "Sussurro" ou o nome da sala
+	Remetente    string    `json:"remetente"`
+	Apelido      string    `json:"apelido"`
+	Destinatario string    `json:"destinatario"` //Usado só nos sussurros
+	Texto        string    `json:"texto"`
+	Horario      time.Time `json:"horario"`
+}
+
+// Resumo de uma sala de chat enviado na listagem
+type ResumoSala struct {
+	Nome    string   `json:"nome"`
+	Membros []string `json:"membros"`
+}
+
+// Constantes do chat
+const (
+	CanalLobby      = "Lobby"
+	CanalGrupo      = "Grupo"
+	CanalSussurro   = "Sussurro"
+	MaxTextoChat    = 300 //Quantidade máxima de caracteres por mensagem
+	MinApelido      = 3
+	MaxApelido      = 16
+	MaxNomeSala     = 20
+	MaxSalasJogador = 5 //Quantidade máxima de salas que um jogador participa ao mesmo tempo
+)
+
+// Variáveis do chat
+var (
+	apelidos     = make(map[string]string)   //Apelido de cada jogador
+	donosApelido = make(map[string]string)   //Id do dono de cada apelido, em minúsculas
+	salas        = make(map[string][]string) //Membros de cada sala de chat, na ordem de entrada
+	muChat       sync.Mutex                  //Mutex para sincronizar apelidos e salas
+)
+
+// Função para buscar o apelido do jogador, vazio se não escolheu nenhum
+func apelidoDe(id string) string {
+	muChat.Lock()
+	defer muChat.Unlock()
+	return apelidos[id]
+}
+
+// Função para montar a resposta de chat com o apelido atual do remetente
+func novaMensagemChat(id, canal, texto string) Resposta {
+	chat := &MensagemChat{Canal: canal, Remetente: id, Apelido: apelidoDe(id), Texto: texto, Horario: time.Now()}
+	return Resposta{Tipo: "Chat", Mensagem: texto, Chat: chat}
+}
+
+// Função para validar o texto de uma mensagem de chat
+func validarTexto(texto string) error {
+	if strings.TrimSpace(texto) == "" || texto == "None" {
+		return fmt.Errorf("Mensagem vazia")
+	}
+	if utf8.RuneCountInString(texto) > MaxTextoChat {
+		return fmt.Errorf("Mensagem com mais de %d caracteres", MaxTextoChat)
+	}
+	return nil
+}
+
+// Função para validar nomes de apelidos e salas: só letras, números, "_" e "-", com pelo menos uma letra
+func validarNomeChat(nome string, minimo, maximo int) error {
+	tamanho := utf8.RuneCountInString(nome)
+	if tamanho < minimo || tamanho > maximo {
+		return fmt.Errorf("O nome %s deve ter de %d a %d caracteres", nome, minimo, maximo)
+	}
+	temLetra := false
+	for _, c := range nome {
+		if unicode.IsLetter(c) {
+			temLetra = true
+		} else if !unicode.IsDigit(c) && c != '_' && c != '-' {
+			return fmt.Errorf("O nome %s só pode ter letras, números, _ e -", nome)
+		}
+	}
+	//Nomes só com números seriam confundidos com os ids dos jogadores
+	if !temLetra {
+		return fmt.Errorf("O nome %s precisa ter pelo menos uma letra", nome)
+	}
+	return nil
+}
+
+// Função para enviar uma mensagem de chat ao remetente como erro
+func enviarErroChat(conn net.Conn, mensagem string) {
+	enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: mensagem})
+}
+
+// Função para escolher o apelido do jogador, único sem diferenciar maiúsculas
+func definirApelido(conn net.Conn, id, apelido string) {
+	apelido = strings.TrimSpace(apelido)
+	if err := validarNomeChat(apelido, MinApelido, MaxApelido); err != nil {
+		enviarErroChat(conn, err.Error())
+		return
+	}
+	if ehIA(apelido) {
+		enviarErroChat(conn, fmt.Sprintf("Apelidos não podem começar com %s", PrefixoIA))
+		return
+	}
+
+	chave := strings.ToLower(apelido)
+	muChat.Lock()
+	if dono, existe := donosApelido[chave]; existe && dono != id {
+		muChat.Unlock()
+		enviarErroChat(conn, fmt.Sprintf("O apelido %s já está em uso", apelido))
+		return
+	}
+	if antigo, existe := apelidos[id]; existe {
+		delete(donosApelido, strings.ToLower(antigo))
+	}
+	apelidos[id] = apelido
+	donosApelido[chave] = id
+	muChat.Unlock()
+
+	enviarResposta(conn, Resposta{Tipo: "Apelido", Mensagem: apelido})
+
+	//Log do servidor
+	color.Cyan("Jogador %s agora se chama %s", id, apelido)
+}
+
+// Função para encontrar um jogador conectado pelo id ou pelo apelido
+func buscarJogadorOnline(alvo string) (string, bool) {
+	alvo = strings.TrimSpace(alvo)
+	muChat.Lock()
+	if dono, existe := donosApelido[strings.ToLower(alvo)]; existe {
+		alvo = dono
+	}
+	muChat.Unlock()
+
+	muClientes.RLock()
+	defer muClientes.RUnlock()
+	_, conectado := clientes[alvo]
+	return alvo, conectado
+}
+
+// Função para enviar uma mensagem a todos os jogadores conectados no lobby
+func enviarLobby(conn net.Conn, id, texto string) {
+	if err := validarTexto(texto); err != nil {
+		enviarErroChat(conn, err.Error())
+		return
+	}
+	if err := verificarMensagem(id, texto); err != nil {
+		enviarErroModeracao(conn, err)
+		return
+	}
+
+	muClientes.RLock()
+	ids := make([]string, 0, len(clientes))
+	for idCliente := range clientes {
+		ids = append(ids, idCliente)
+	}
+	muClientes.RUnlock()
+
+	//Quem bloqueou o remetente não recebe a mensagem
+	ids = filtrarBloqueados(id, ids)
+	enviarParaJogadores(novaMensagemChat(id, CanalLobby, texto), ids...)
+
+	//Log do servidor
+	color.Yellow("Lobby: mensagem de %s para %d jogadores", id, len(ids))
+}
+
+// Função para sussurrar a um jogador conectado, o remetente recebe uma cópia
+func sussurrar(conn net.Conn, id, alvo, texto string) {
+	if err := validarTexto(texto); err != nil {
+		enviarErroChat(conn, err.Error())
+		return
+	}
+	destinatario, online := buscarJogadorOnline(alvo)
+	if !online {
+		enviarErroChat(conn, fmt.Sprintf("Jogador %s não existe ou não está online", alvo))
+		return
+	}
+	if destinatario == id {
+		enviarErroChat(conn, "Não é possível sussurrar para si mesmo")
+		return
+	}
+	if err := verificarBloqueio(id, destinatario); err != nil {
+		enviarErroModeracao(conn, err)
+		return
+	}
+	if err := verificarMensagem(id, texto); err != nil {
+		enviarErroModeracao(conn, err)
+		return
+	}
+
+	resposta := novaMensagemChat(id, CanalSussurro, texto)
+	resposta.Chat.Destinatario = destinatario
+	enviarParaJogadores(resposta, destinatario)
+	enviarResposta(conn, resposta)
+
+	//Só conversa com um oponente do grupo conta para as missões
+	if mesmoGrupo(id, destinatario) {
+		registrarEventoMissao(id, "Mensagem", 1)
+	}
+
+	//Log do servidor
+	color.Yellow("Sussurro de %s >>> %s", id, destinatario)
+}
+
+// Função para entrar em uma sala de chat, criando a sala se ainda não existir
+func entrarSala(conn net.Conn, id, nome string) {
+	nome = strings.TrimSpace(nome)
+	if err := validarNomeChat(nome, 1, MaxNomeSala); err != nil {
+		enviarErroChat(conn, err.Error())
+		return
+	}
+	for _, reservado := range []string{CanalLobby, CanalGrupo, CanalSussurro} {
+		if strings.EqualFold(nome, reservado) {
+			enviarErroChat(conn, fmt.Sprintf("O nome %s é reservado", nome))
+			return
+		}
+	}
+
+	muChat.Lock()
+	if contem(salas[nome], id) {
+		muChat.Unlock()
+		enviarErroChat(conn, fmt.Sprintf("Você já está na sala %s", nome))
+		return
+	}
+	if len(salasDe(id)) >= MaxSalasJogador {
+		muChat.Unlock()
+		enviarErroChat(conn, fmt.Sprintf("Você já participa de %d salas", MaxSalasJogador))
+		return
+	}
+	salas[nome] = append(salas[nome], id)
+	membros := append([]string(nil), salas[nome]...)
+	muChat.Unlock()
+
+	resposta := Resposta{Tipo: "Sala", Mensagem: fmt.Sprintf("Jogador %s entrou na sala %s (%d membro(s))", descreverJogador(id), nome, len(membros))}
+	enviarParaJogadores(resposta, membros...)
+
+	//Log do servidor
+	color.Cyan("Jogador %s entrou na sala %s", id, nome)
+}
+
+// Função para sair de uma sala de chat, a sala vazia deixa de existir
+func sairSala(conn net.Conn, id, nome string) {
+	nome = strings.TrimSpace(nome)
+	restantes, ok := removerDaSala(id, nome)
+	if !ok {
+		enviarErroChat(conn, fmt.Sprintf("Você não está na sala %s", nome))
+		return
+	}
+
+	resposta := Resposta{Tipo: "Sala", Mensagem: fmt.Sprintf("Jogador %s saiu da sala %s", descreverJogador(id), nome)}
+	enviarParaJogadores(resposta, append(restantes, id)...)
+
+	//Log do servidor
+	color.Cyan("Jogador %s saiu da sala %s", id, nome)
+}
+
+// Função para retirar o jogador da sala. Retorna os membros restantes e se o jogador estava na sala
+func removerDaSala(id, nome string) ([]string, bool) {
+	muChat.Lock()
+	defer muChat.Unlock()
+	membros := salas[nome]
+	for i, membro := range membros {
+		if membro == id {
+			membros = append(membros[:i:i], membros[i+1:]...)
+			if len(membros) == 0 {
+				delete(salas, nome)
+			} else {
+				salas[nome] = membros
+			}
+			return append([]string(nil), membros...), true
+		}
+	}
+	return nil, false
+}
+
+// Função para listar as salas em que o jogador está. Precisa de muChat bloqueado
+func salasDe(id string) []string {
+	lista := make([]string, 0)
+	for nome, membros := range salas {
+		if contem(membros, id) {
+			lista = append(lista, nome)
+		}
+	}
+	sort.Strings(lista)
+	return lista
+}
+
+// Função para enviar a lista das salas existentes com os membros
+func listarSalas(conn net.Conn) {
+	muChat.Lock()
+	lista := make([]ResumoSala, 0, len(salas))
+	for nome, membros := range salas {
+		lista = append(lista, ResumoSala{Nome: nome, Membros: append([]string(nil), membros...)})
+	}
+	muChat.Unlock()
+
+	sort.Slice(lista, func(i, j int) bool { return lista[i].Nome < lista[j].Nome })
+	enviarResposta(conn, Resposta{Tipo: "Lista_Salas", Salas: lista})
+}
+
+// Função para enviar uma mensagem aos membros de uma sala que o jogador participa
+func enviarSala(conn net.Conn, id, nome, texto string) {
+	if err := validarTexto(texto); err != nil {
+		enviarErroChat(conn, err.Error())
+		return
+	}
+
+	muChat.Lock()
+	membros := append([]string(nil), salas[nome]...)
+	muChat.Unlock()
+	if !contem(membros, id) {
+		enviarErroChat(conn, fmt.Sprintf("Você não está na sala %s", nome))
+		return
+	}
+	if err := verificarMensagem(id, texto); err != nil {
+		enviarErroModeracao(conn, err)
+		return
+	}
+
+	enviarParaJogadores(novaMensagemChat(id, nome, texto), filtrarBloqueados(id, membros)...)
+
+	//Log do servidor
+	color.Yellow("Sala %s: mensagem de %s", nome, id)
+}
+
+// Função para descrever o jogador pelo id e apelido, se tiver
+func descreverJogador(id string) string {
+	if apelido := apelidoDe(id); apelido != "" {
+		return fmt.Sprintf("%s (%s)", id, apelido)
+	}
+	return id
+}
+
+// Função para retirar o jogador desconectado das salas e liberar o apelido
+func limparChat(id string) {
+	muChat.Lock()
+	nomes := salasDe(id)
+	if apelido, existe := apelidos[id]; existe {
+		delete(donosApelido, strings.ToLower(apelido))
+		delete(apelidos, id)
+	}
+	muChat.Unlock()
+
+	for _, nome := range nomes {
+		if restantes, ok := removerDaSala(id, nome); ok {
+			resposta := Resposta{Tipo: "Sala", Mensagem: fmt.Sprintf("Jogador %s saiu da sala %s", id, nome)}
+			enviarParaJogadores(resposta, restantes...)
+		}
+	}
+}
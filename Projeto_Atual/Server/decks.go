@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// Variáveis dos decks salvos
+var (
+	decks           = make(map[string]map[string][]Tanque) //Decks salvos de cada jogador por nome
+	deckSelecionado = make(map[string]string)              //Nome do deck escolhido por cada jogador para batalhar
+	muDecks         sync.RWMutex                           //Mutex para sincronizar os decks
+)
+
+// Deck salvo pelo jogador
+type Deck struct {
+	Nome   string   `json:"nome"`
+	Cartas []Tanque `json:"cartas"`
+}
+
+// Função para criar ou substituir um deck com nome, validando com a coleção do jogador
+func criarDeck(conn net.Conn, id, nome string, cartas []Tanque) {
+	var resposta Resposta
+
+	if nome == "" {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "O deck precisa de um nome"
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	deck, err := validarDeck(id, cartas)
+	if err != nil {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	muDecks.Lock()
+	if decks[id] == nil {
+		decks[id] = make(map[string][]Tanque)
+	}
+	decks[id][nome] = deck
+	muDecks.Unlock()
+
+	resposta.Tipo = "Deck_Criado"
+	resposta.Mensagem = nome
+	resposta.Cartas = deck
+	enviarResposta(conn, resposta)
+
+	//Log do servidor
+	color.Cyan("Jogador %s salvou o deck %s", id, nome)
+}
+
+// Função para listar os decks salvos do jogador em ordem alfabética
+func listarDecks(conn net.Conn, id string) {
+	muDecks.RLock()
+	lista := make([]Deck, 0, len(decks[id]))
+	for nome, cartas := range decks[id] {
+		lista = append(lista, Deck{Nome: nome, Cartas: cartas})
+	}
+	selecionado := deckSelecionado[id]
+	muDecks.RUnlock()
+
+	sort.Slice(lista, func(i, j int) bool { return lista[i].Nome < lista[j].Nome })
+
+	resposta := Resposta{Tipo: "Lista_Decks", Mensagem: selecionado, Decks: lista}
+	enviarResposta(conn, resposta)
+}
+
+// Função para selecionar o deck usado nas próximas batalhas
+func selecionarDeck(conn net.Conn, id, nome string) {
+	var resposta Resposta
+
+	muDecks.Lock()
+	_, existe := decks[id][nome]
+	if existe {
+		deckSelecionado[id] = nome
+	}
+	muDecks.Unlock()
+
+	if !existe {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Deck %s não existe", nome)
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	resposta.Tipo = "Deck_Selecionado"
+	resposta.Mensagem = nome
+	enviarResposta(conn, resposta)
+}
+
+// Função para pegar uma cópia do deck selecionado do jogador, se ainda for válido e tiver o tamanho pedido
+func deckSelecionadoValido(id string, tamanho int) ([]Tanque, bool) {
+	muDecks.RLock()
+	nome, existe := deckSelecionado[id]
+	cartas := decks[id][nome]
+	muDecks.RUnlock()
+
+	if !existe || len(cartas) != tamanho {
+		return nil, false
+	}
+
+	//A coleção pode ter mudado desde a criação do deck
+	deck, err := validarDeck(id, cartas)
+	if err != nil {
+		color.Red("Deck %s do jogador %s inválido: %v", nome, id, err)
+		return nil, false
+	}
+	return deck, true
+}
+
+// Função para verificar se as cartas formam um deck válido com a coleção do jogador.
+// Retorna uma cópia com as cartas da coleção do servidor, não as enviadas pelo cliente
+func validarDeck(id string, cartas []Tanque) ([]Tanque, error) {
+	if !tamanhoDeckPermitido(len(cartas)) {
+		return nil, fmt.Errorf("Nenhuma regra de batalha aceita decks com %d cartas", len(cartas))
+	}
+
+	muColecoes.RLock()
+	defer muColecoes.RUnlock()
+
+	posicoes, err := encontrarNaColecao(colecoes[id], cartas)
+	if err != nil {
+		return nil, err
+	}
+
+	deck := make([]Tanque, 0, len(cartas))
+	for _, i := range posicoes {
+		deck = append(deck, colecoes[id][i])
+	}
+	return deck, nil
+}
+
+// Função para encontrar as posições das cartas na coleção, cada carta da coleção só pode ser usada uma vez.
+// Deve ser chamada com muColecoes bloqueado
+func encontrarNaColecao(colecao []Tanque, cartas []Tanque) ([]int, error) {
+	usadas := make([]bool, len(colecao))
+	posicoes := make([]int, 0, len(cartas))
+	for _, carta := range cartas {
+		encontrada := false
+		for i, c := range colecao {
+			if !usadas[i] && mesmaCarta(c, carta) {
+				usadas[i] = true
+				posicoes = append(posicoes, i)
+				encontrada = true
+				break
+			}
+		}
+		if !encontrada {
+			return nil, fmt.Errorf("A carta %s não está disponível na sua coleção", carta.Modelo)
+		}
+	}
+	return posicoes, nil
+}
+
+// Função para comparar cartas, pelo id da instância quando a carta pedida tem um
+func mesmaCarta(c, carta Tanque) bool {
+	if carta.Instancia != 0 {
+		return c.Instancia == carta.Instancia
+	}
+	return c.Modelo == carta.Modelo && c.Vida == carta.Vida && c.Ataque == carta.Ataque
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// Resumo de uma batalha em andamento que pode ser assistida
+type ResumoBatalha struct {
+	Id           int      `json:"id"`
+	Modo         string   `json:"modo"`
+	Regras       string   `json:"regras"`
+	Jogadores    []string `json:"jogadores"`
+	UltimoTurno  string   `json:"ultimo_turno"`
+	Espectadores int      `json:"espectadores"`
+}
+
+// Variáveis dos espectadores
+var (
+	assistindo     = make(map[string]*Batalha) //Batalha que cada espectador está assistindo
+	muEspectadores sync.Mutex                  //Mutex para sincronizar espectadores e o estado público das batalhas
+)
+
+// Função para enviar um evento público da batalha aos participantes e espectadores.
+// O envio acontece com muEspectadores bloqueado para que quem entra receba o estado atual antes do próximo evento
+func (b *Batalha) transmitir(resposta Resposta) {
+	muEspectadores.Lock()
+	defer muEspectadores.Unlock()
+
+	if resposta.Tipo == "Turno_Realizado" {
+		b.Campo = resposta.Cartas
+		b.UltimoTurno = resposta.Mensagem
+	}
+	enviarParaJogadores(resposta, append(b.ids(), b.Espectadores...)...)
+
+	//Espectadores saem junto com o fim da batalha
+	if resposta.Tipo == "Fim_Batalha" {
+		b.Encerrada = true
+		for _, id := range b.Espectadores {
+			delete(assistindo, id)
+		}
+		b.Espectadores = nil
+	}
+}
+
+// Função para montar o resumo da batalha. Deve ser chamada com muEspectadores bloqueado
+func (b *Batalha) resumo() ResumoBatalha {
+	return ResumoBatalha{
+		Id:           b.Id,
+		Modo:         nomeModo(b.Modo),
+		Regras:       b.Regras.Nome,
+		Jogadores:    b.ids(),
+		UltimoTurno:  b.UltimoTurno,
+		Espectadores: len(b.Espectadores),
+	}
+}
+
+// Função para avisar participantes e espectadores da quantidade de espectadores.
+// Deve ser chamada com muEspectadores bloqueado
+func (b *Batalha) notificarEspectadores() {
+	resposta := Resposta{
+		Tipo:     "Espectadores",
+		Mensagem: fmt.Sprintf("%d espectador(es) assistindo a batalha %d", len(b.Espectadores), b.Id),
+	}
+	enviarParaJogadores(resposta, append(b.ids(), b.Espectadores...)...)
+}
+
+// Função para listar as batalhas em andamento ordenadas pelo id
+func listarBatalhasAtivas(conn net.Conn) {
+	muBatalhas.RLock()
+	vistas := make(map[*Batalha]bool)
+	for _, batalha := range batalhas {
+		vistas[batalha] = true
+	}
+	muBatalhas.RUnlock()
+
+	lista := make([]ResumoBatalha, 0, len(vistas))
+	muEspectadores.Lock()
+	for batalha := range vistas {
+		if !batalha.Encerrada {
+			lista = append(lista, batalha.resumo())
+		}
+	}
+	muEspectadores.Unlock()
+
+	sort.Slice(lista, func(i, j int) bool { return lista[i].Id < lista[j].Id })
+	enviarResposta(conn, Resposta{Tipo: "Batalhas_Ativas", Batalhas: lista})
+}
+
+// Função para inscrever o jogador como espectador da batalha com o id informado
+func assistirBatalha(conn net.Conn, id, mensagem string) {
+	var resposta Resposta
+
+	idBatalha, err := strconv.Atoi(strings.TrimSpace(mensagem))
+	if err != nil {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Id de batalha inválido"
+		enviarResposta(conn, resposta)
+		return
+	}
+	if emBatalha(id) {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Não é possível assistir durante uma batalha"
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	var batalha *Batalha
+	muBatalhas.RLock()
+	for _, b := range batalhas {
+		if b.Id == idBatalha {
+			batalha = b
+			break
+		}
+	}
+	muBatalhas.RUnlock()
+
+	muEspectadores.Lock()
+	defer muEspectadores.Unlock()
+
+	if batalha == nil || batalha.Encerrada {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Batalha %d não está em andamento", idBatalha)
+		enviarResposta(conn, resposta)
+		return
+	}
+	if atual, existe := assistindo[id]; existe {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Você já está assistindo a batalha %d", atual.Id)
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	assistindo[id] = batalha
+	batalha.Espectadores = append(batalha.Espectadores, id)
+
+	//Estado atual da batalha para o novo espectador
+	resumo := batalha.resumo()
+	resposta.Tipo = "Assistindo"
+	resposta.Mensagem = fmt.Sprintf("Assistindo a batalha %d (%s, regras %s) entre %s", resumo.Id, resumo.Modo, resumo.Regras, strings.Join(resumo.Jogadores, ", "))
+	resposta.Cartas = batalha.Campo
+	resposta.Batalhas = []ResumoBatalha{resumo}
+	enviarResposta(conn, resposta)
+
+	batalha.notificarEspectadores()
+
+	//Log do servidor
+	color.Cyan("Jogador %s assistindo a batalha %d", id, batalha.Id)
+}
+
+// Função para o espectador deixar de assistir a batalha, avisando o jogador se solicitado
+func pararDeAssistir(id string, avisar bool) bool {
+	muEspectadores.Lock()
+	defer muEspectadores.Unlock()
+
+	batalha, existe := assistindo[id]
+	if !existe {
+		return false
+	}
+	delete(assistindo, id)
+
+	restantes := make([]string, 0, len(batalha.Espectadores))
+	for _, espectador := range batalha.Espectadores {
+		if espectador != id {
+			restantes = append(restantes, espectador)
+		}
+	}
+	batalha.Espectadores = restantes
+
+	if avisar {
+		resposta := Resposta{Tipo: "Assistir_Encerrado", Mensagem: fmt.Sprintf("Você parou de assistir a batalha %d", batalha.Id)}
+		enviarParaJogadores(resposta, id)
+	}
+	batalha.notificarEspectadores()
+	return true
+}
+
+// Função para tratar o pedido do espectador de parar de assistir
+func sairDeAssistir(conn net.Conn, id string) {
+	if !pararDeAssistir(id, true) {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: "Você não está assistindo nenhuma batalha"})
+	}
+}
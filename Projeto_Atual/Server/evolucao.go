@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Curva de evolução de uma classe de tanque, em bônus percentual sobre os atributos do catálogo
+type CurvaEvolucao struct {
+	Vida        []int //Bônus de vida de cada quantidade de estrelas, começando em 1 estrela
+	Ataque      []int //Bônus de ataque de cada quantidade de estrelas
+	VidaNivel   int   //Bônus de vida por nível acima do 1
+	AtaqueNivel int   //Bônus de ataque por nível acima do 1
+}
+
+// Constantes da evolução das cartas
+const (
+	CopiasFusao        = 3   //Cópias do mesmo modelo e estrelas para uma fusão
+	MaxEstrelas        = 5   //Estrelas máximas de uma carta
+	MaxNivel           = 10  //Nível máximo de uma carta
+	ExperienciaNivel   = 100 //Experiência para subir cada nível
+	ExperienciaBatalha = 20  //Experiência de cada carta do deck por batalha jogada
+	ExperienciaVitoria = 20  //Experiência extra de cada carta do deck por vitória
+)
+
+// Curvas de cada classe do catálogo: leves ganham mais ataque, pesados mais vida
+var curvasEvolucao = map[string]CurvaEvolucao{
+	"(Light)":  {Vida: []int{0, 15, 35, 60, 100}, Ataque: []int{0, 20, 45, 75, 120}, VidaNivel: 3, AtaqueNivel: 4},
+	"(Medium)": {Vida: []int{0, 20, 40, 70, 110}, Ataque: []int{0, 15, 35, 60, 100}, VidaNivel: 3, AtaqueNivel: 3},
+	"(Heavy)":  {Vida: []int{0, 25, 50, 80, 120}, Ataque: []int{0, 10, 25, 45, 80}, VidaNivel: 4, AtaqueNivel: 2},
+}
+
+// Função para buscar o modelo no catálogo dos pacotes ou entre os exclusivos de temporada
+func buscarModelo(modelo string) (ModeloCarta, bool) {
+	for _, m := range append(pacote_1, modelosExclusivos...) {
+		if m.Modelo == modelo {
+			return m, true
+		}
+	}
+	return ModeloCarta{}, false
+}
+
+// Função para buscar a curva de evolução pela classe no nome do modelo
+func curvaDoModelo(modelo string) (CurvaEvolucao, bool) {
+	for classe, curva := range curvasEvolucao {
+		if strings.HasSuffix(modelo, classe) {
+			return curva, true
+		}
+	}
+	return CurvaEvolucao{}, false
+}
+
+// Função para recalcular vida e ataque da carta pelas estrelas e nível. Cartas fora do catálogo não mudam
+func atualizarAtributos(carta *Tanque) {
+	base, existe := buscarModelo(carta.Modelo)
+	curva, temCurva := curvaDoModelo(carta.Modelo)
+	if !existe || !temCurva || carta.Estrelas < 1 || carta.Nivel < 1 {
+		return
+	}
+	estrela := min(carta.Estrelas, len(curva.Vida)) - 1
+	carta.Vida = base.Vida * (100 + curva.Vida[estrela] + (carta.Nivel-1)*curva.VidaNivel) / 100
+	carta.Ataque = base.Ataque * (100 + curva.Ataque[estrela] + (carta.Nivel-1)*curva.AtaqueNivel) / 100
+}
+
+// Função para fundir cópias do mesmo modelo e estrelas em uma carta com uma estrela a mais.
+// A primeira carta enviada é a que evolui, as outras são consumidas
+func fundirCartas(conn net.Conn, id string, cartas []Tanque) {
+	var resposta Resposta
+
+	if len(cartas) != CopiasFusao {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("A fusão precisa de %d cópias do mesmo modelo", CopiasFusao)
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	muColecoes.Lock()
+	posicoes, err := encontrarNaColecao(colecoes[id], cartas)
+	if err != nil {
+		muColecoes.Unlock()
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	//Conferência com as cartas do servidor, não as enviadas pelo cliente
+	resultado := colecoes[id][posicoes[0]]
+	consumidas := make([]Tanque, 0, len(posicoes)-1)
+	for _, i := range posicoes[1:] {
+		carta := colecoes[id][i]
+		if carta.Modelo != resultado.Modelo || carta.Estrelas != resultado.Estrelas || carta.Instancia == 0 {
+			muColecoes.Unlock()
+			resposta.Tipo = "Erro"
+			resposta.Mensagem = "As cartas da fusão precisam ser do mesmo modelo e ter as mesmas estrelas"
+			enviarResposta(conn, resposta)
+			return
+		}
+		consumidas = append(consumidas, carta)
+	}
+	if _, temCurva := curvaDoModelo(resultado.Modelo); !temCurva || resultado.Instancia == 0 || resultado.Estrelas >= MaxEstrelas {
+		muColecoes.Unlock()
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("%s não pode evoluir mais", resultado.Modelo)
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	//Carta evoluída mantém o maior nível entre as cópias
+	for _, carta := range consumidas {
+		if carta.Nivel > resultado.Nivel || (carta.Nivel == resultado.Nivel && carta.Experiencia > resultado.Experiencia) {
+			resultado.Nivel = carta.Nivel
+			resultado.Experiencia = carta.Experiencia
+		}
+	}
+	resultado.Estrelas++
+	atualizarAtributos(&resultado)
+
+	colecoes[id][posicoes[0]] = resultado
+	transferirCartas(id, posicoes[1:], "")
+	colecao := append([]Tanque{}, colecoes[id]...)
+	muColecoes.Unlock()
+
+	instancias := make([]string, 0, len(consumidas))
+	for _, carta := range consumidas {
+		instancias = append(instancias, fmt.Sprintf("%d", carta.Instancia))
+	}
+	registrarPosse([]Tanque{resultado}, id, fmt.Sprintf("Fusão para %d estrelas com as cartas %s", resultado.Estrelas, strings.Join(instancias, ", ")))
+	registrarPosse(consumidas, id, fmt.Sprintf("Consumida na fusão da carta %d", resultado.Instancia))
+
+	resposta.Tipo = "Fusao"
+	resposta.Mensagem = fmt.Sprintf("%s evoluiu para %d estrelas (Vida %d / Ataque %d)", resultado.Modelo, resultado.Estrelas, resultado.Vida, resultado.Ataque)
+	resposta.Cartas = colecao
+	enviarResposta(conn, resposta)
+	registrarEventoMissao(id, "Forja", 1)
+
+	//Log do servidor
+	color.Cyan("Jogador %s fundiu %s para %d estrelas", id, resultado.Modelo, resultado.Estrelas)
+}
+
+// Função para dar experiência às cartas do deck de cada participante ao fim da batalha
+func ganharExperiencia(batalha *Batalha) {
+	for _, p := range batalha.Participantes {
+		experiencia := ExperienciaBatalha
+		if batalha.venceu(p.Id) {
+			experiencia += ExperienciaVitoria
+		}
+
+		instancias := make(map[int]bool)
+		for _, carta := range p.DeckInicial {
+			if carta.Instancia != 0 {
+				instancias[carta.Instancia] = true
+			}
+		}
+		if len(instancias) == 0 {
+			continue
+		}
+
+		subiram := make([]string, 0)
+		muColecoes.Lock()
+		for i := range colecoes[p.Id] {
+			carta := &colecoes[p.Id][i]
+			if !instancias[carta.Instancia] || carta.Nivel < 1 || carta.Nivel >= MaxNivel {
+				continue
+			}
+			carta.Experiencia += experiencia
+			nivelAnterior := carta.Nivel
+			for carta.Experiencia >= ExperienciaNivel && carta.Nivel < MaxNivel {
+				carta.Experiencia -= ExperienciaNivel
+				carta.Nivel++
+			}
+			if carta.Nivel == MaxNivel {
+				carta.Experiencia = 0
+			}
+			if carta.Nivel > nivelAnterior {
+				atualizarAtributos(carta)
+				subiram = append(subiram, fmt.Sprintf("%s #%d (nível %d)", carta.Modelo, carta.Serie, carta.Nivel))
+			}
+		}
+		colecao := append([]Tanque{}, colecoes[p.Id]...)
+		muColecoes.Unlock()
+
+		mensagem := fmt.Sprintf("Suas cartas da batalha ganharam %d de experiência", experiencia)
+		if len(subiram) > 0 {
+			mensagem += ". Subiram de nível: " + strings.Join(subiram, ", ")
+		}
+		enviarParaJogadores(Resposta{Tipo: "Evolucao", Mensagem: mensagem, Cartas: colecao}, p.Id)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// Raridade de uma classe de tanque, com a sucata ganha ao desmontar e o custo para fabricar
+type Raridade struct {
+	Nome      string
+	Classe    string
+	Desmontar int
+	Fabricar  int
+}
+
+// Raridades do catálogo pela classe do modelo
+var raridades = []Raridade{
+	{"Comum", "(Light)", 5, 40},
+	{"Incomum", "(Medium)", 15, 100},
+	{"Rara", "(Heavy)", 40, 400},
+}
+
+// Variáveis da sucata
+var (
+	sucatas  = make(map[string]int) //Sucata de cada jogador
+	muSucata sync.Mutex             //Mutex para sincronizar a sucata
+)
+
+// Função para buscar a raridade pela classe no nome do modelo
+func raridadeDoModelo(modelo string) (Raridade, bool) {
+	for _, r := range raridades {
+		if strings.HasSuffix(modelo, r.Classe) {
+			return r, true
+		}
+	}
+	return Raridade{}, false
+}
+
+// Função para calcular a sucata de uma carta, cartas fundidas valem as cópias usadas nelas
+func valorSucata(carta Tanque) int {
+	raridade, existe := raridadeDoModelo(carta.Modelo)
+	if !existe {
+		return 0
+	}
+	valor := raridade.Desmontar
+	for i := 1; i < carta.Estrelas; i++ {
+		valor *= CopiasFusao
+	}
+	return valor
+}
+
+// Função para consultar a sucata do jogador
+func sucataJogador(id string) int {
+	muSucata.Lock()
+	defer muSucata.Unlock()
+	return sucatas[id]
+}
+
+// Função para desmontar cartas da coleção em sucata
+func desmontarCartas(conn net.Conn, id string, cartas []Tanque) {
+	var resposta Resposta
+
+	if len(cartas) == 0 {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Escolha pelo menos uma carta para desmontar"
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	muColecoes.Lock()
+	posicoes, err := encontrarNaColecao(colecoes[id], cartas)
+	if err != nil {
+		muColecoes.Unlock()
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	total := 0
+	for _, i := range posicoes {
+		carta := colecoes[id][i]
+		valor := valorSucata(carta)
+		if valor == 0 || carta.Instancia == 0 {
+			muColecoes.Unlock()
+			resposta.Tipo = "Erro"
+			resposta.Mensagem = fmt.Sprintf("%s não pode ser desmontada", carta.Modelo)
+			enviarResposta(conn, resposta)
+			return
+		}
+		total += valor
+	}
+	desmontadas := transferirCartas(id, posicoes, "")
+	colecao := append([]Tanque{}, colecoes[id]...)
+	muColecoes.Unlock()
+
+	for _, carta := range desmontadas {
+		registrarPosse([]Tanque{carta}, id, fmt.Sprintf("Desmontada por %d de sucata", valorSucata(carta)))
+	}
+
+	muSucata.Lock()
+	sucatas[id] += total
+	saldo := sucatas[id]
+	muSucata.Unlock()
+
+	resposta.Tipo = "Sucata"
+	resposta.Mensagem = fmt.Sprintf("%d carta(s) desmontada(s) por %d de sucata. Sucata: %d", len(desmontadas), total, saldo)
+	resposta.Cartas = colecao
+	enviarResposta(conn, resposta)
+
+	//Log do servidor
+	color.Cyan("Jogador %s desmontou %d cartas por %d de sucata", id, len(desmontadas), total)
+}
+
+// Função para fabricar uma carta do catálogo gastando sucata. O modelo pode ser informado sem a classe
+func fabricarCarta(conn net.Conn, id, nome string) {
+	var resposta Resposta
+
+	nome = strings.TrimSpace(nome)
+	var modelo ModeloCarta
+	encontrado := false
+	for _, m := range pacote_1 {
+		semClasse, _, _ := strings.Cut(m.Modelo, " (")
+		if strings.EqualFold(m.Modelo, nome) || strings.EqualFold(semClasse, nome) {
+			modelo, encontrado = m, true
+			break
+		}
+	}
+	raridade, temRaridade := raridadeDoModelo(modelo.Modelo)
+	if !encontrado || !temRaridade {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Modelo %s não existe no catálogo", nome)
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	//Conferir e descontar a sucata de uma vez
+	muSucata.Lock()
+	if sucatas[id] < raridade.Fabricar {
+		saldo := sucatas[id]
+		muSucata.Unlock()
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Sucata insuficiente: %s custa %d e você tem %d", modelo.Modelo, raridade.Fabricar, saldo)
+		enviarResposta(conn, resposta)
+		return
+	}
+	sucatas[id] -= raridade.Fabricar
+	saldo := sucatas[id]
+	muSucata.Unlock()
+
+	carta := cunharCarta(modelo, id, fmt.Sprintf("Fabricada por %d de sucata", raridade.Fabricar))
+
+	muColecoes.Lock()
+	colecoes[id] = append(colecoes[id], carta)
+	colecao := append([]Tanque{}, colecoes[id]...)
+	muColecoes.Unlock()
+
+	resposta.Tipo = "Sucata"
+	resposta.Mensagem = fmt.Sprintf("%s #%d fabricada por %d de sucata. Sucata: %d", carta.Modelo, carta.Serie, raridade.Fabricar, saldo)
+	resposta.Cartas = colecao
+	enviarResposta(conn, resposta)
+	registrarEventoMissao(id, "Forja", 1)
+
+	//Log do servidor
+	color.Cyan("Jogador %s fabricou %s", id, carta.Modelo)
+}
+
+// Função para enviar o catálogo de modelos com a raridade e o custo de fabricação
+func enviarCatalogo(conn net.Conn) {
+	cartas := make([]Tanque, 0, len(pacote_1))
+	vistos := make(map[string]bool)
+	for _, m := range pacote_1 {
+		if !vistos[m.Modelo] {
+			vistos[m.Modelo] = true
+			cartas = append(cartas, Tanque{Modelo: m.Modelo, Id_jogador: m.Id_jogador, Vida: m.Vida, Ataque: m.Ataque})
+		}
+	}
+
+	custos := make([]string, 0, len(raridades))
+	for _, r := range raridades {
+		custos = append(custos, fmt.Sprintf("%s %s: desmonta por %d, fabrica por %d", r.Nome, r.Classe, r.Desmontar, r.Fabricar))
+	}
+	enviarResposta(conn, Resposta{Tipo: "Catalogo", Mensagem: strings.Join(custos, "; "), Cartas: cartas})
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// Grupo de jogadores pareados, com 2 a 4 membros
+type Grupo struct {
+	Lider   string
+	Membros []string //Na ordem de entrada no grupo
+}
+
+// Variáveis dos grupos
+var (
+	grupos   = make(map[string]*Grupo) //Grupo de cada jogador pareado
+	muGrupos sync.RWMutex              //Mutex para sincronização dos grupos
+)
+
+// Quantidade máxima de jogadores em um grupo
+const MaxMembrosGrupo = 4
+
+// Função para verificar se os 2 jogadores estão no mesmo grupo
+func mesmoGrupo(id1, id2 string) bool {
+	muGrupos.RLock()
+	defer muGrupos.RUnlock()
+	grupo, existe := grupos[id1]
+	return existe && grupos[id2] == grupo
+}
+
+// Função para pegar uma cópia dos membros do grupo do jogador, vazia se não estiver em um grupo
+func membrosGrupo(id string) []string {
+	muGrupos.RLock()
+	defer muGrupos.RUnlock()
+	grupo, existe := grupos[id]
+	if !existe {
+		return nil
+	}
+	return append([]string(nil), grupo.Membros...)
+}
+
+// Função para enviar a lista atualizada de membros para todo o grupo
+func notificarGrupo(membros []string) {
+	resposta := Resposta{Tipo: "Grupo_Atualizado", Mensagem: strings.Join(membros, ",")}
+	enviarParaJogadores(resposta, membros...)
+}
+
+// Função para retirar o jogador do grupo, desfazendo o grupo se sobrar apenas um membro.
+// Retorna os membros restantes e se o grupo foi desfeito
+func removerDoGrupo(id string) ([]string, bool) {
+	muGrupos.Lock()
+	defer muGrupos.Unlock()
+
+	grupo, existe := grupos[id]
+	if !existe {
+		return nil, false
+	}
+	delete(grupos, id)
+
+	restantes := make([]string, 0, len(grupo.Membros))
+	for _, membro := range grupo.Membros {
+		if membro != id {
+			restantes = append(restantes, membro)
+		}
+	}
+	grupo.Membros = restantes
+
+	if len(restantes) < 2 {
+		for _, membro := range restantes {
+			delete(grupos, membro)
+		}
+		return restantes, true
+	}
+
+	if grupo.Lider == id {
+		grupo.Lider = restantes[0]
+	}
+	return append([]string(nil), restantes...), false
+}
+
+// Função para o jogador sair do grupo por vontade própria
+func sairGrupo(conn net.Conn, id string) {
+	var resposta Resposta
+
+	if emBatalha(id) {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Não é possível sair do grupo durante uma batalha"
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	restantes, desfeito := removerDoGrupo(id)
+	if restantes == nil && !desfeito {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Você não está em um grupo"
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	cancelarTrocasDe(id)
+
+	resposta.Tipo = "Grupo_Desfeito"
+	resposta.Mensagem = "Você saiu do grupo"
+	enviarResposta(conn, resposta)
+
+	if desfeito {
+		resposta.Mensagem = fmt.Sprintf("Jogador %s saiu e o grupo foi desfeito", id)
+		enviarParaJogadores(resposta, restantes...)
+	} else {
+		notificarGrupo(restantes)
+	}
+
+	//Log do servidor
+	color.Magenta("Jogador %s saiu do grupo", id)
+}
+
+// Função para iniciar uma batalha 2 contra 2 entre os 4 membros do grupo.
+// A mensagem tem o id do companheiro de equipe e opcionalmente as regras, ex: "3 Rapida"
+func iniciarBatalhaEquipes(conn net.Conn, id, mensagem string) {
+	var resposta Resposta
+
+	campos := strings.Fields(mensagem)
+	membros := membrosGrupo(id)
+	if len(membros) != 4 {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Batalha em equipes precisa de um grupo com 4 jogadores"
+		enviarResposta(conn, resposta)
+		return
+	}
+	if len(campos) == 0 || campos[0] == id || !mesmoGrupo(id, campos[0]) {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Informe o id de um companheiro de equipe do seu grupo"
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	regras, ok := buscarRegras(strings.Join(campos[1:], " "))
+	if !ok {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Regras de batalha %s não existem", strings.Join(campos[1:], " "))
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	//Ordem dos turnos intercalando as equipes: remetente, oponente, companheiro, oponente
+	companheiro := campos[0]
+	oponentes := make([]string, 0, 2)
+	for _, membro := range membros {
+		if membro != id && membro != companheiro {
+			oponentes = append(oponentes, membro)
+		}
+	}
+	ordem := []string{id, oponentes[0], companheiro, oponentes[1]}
+
+	if err := validarLivres(ordem); err != nil {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	batalha, err := novaBatalha(ModoEquipes, ordem, regras, nil, nil)
+	if err != nil {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+	go realizarBatalha(batalha)
+}
+
+// Função para iniciar uma batalha todos contra todos entre os membros do grupo (3 ou 4 jogadores)
+func iniciarBatalhaTodos(conn net.Conn, id, nomeRegras string) {
+	var resposta Resposta
+
+	membros := membrosGrupo(id)
+	if len(membros) < 3 {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Batalha todos contra todos precisa de um grupo com 3 ou 4 jogadores"
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	regras, ok := buscarRegras(nomeRegras)
+	if !ok {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Regras de batalha %s não existem", nomeRegras)
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	if err := validarLivres(membros); err != nil {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	batalha, err := novaBatalha(ModoTodosContraTodos, membros, regras, nil, nil)
+	if err != nil {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+	go realizarBatalha(batalha)
+}
+
+// Função para verificar se nenhum dos jogadores está em batalha
+func validarLivres(ids []string) error {
+	for _, id := range ids {
+		if emBatalha(id) {
+			return fmt.Errorf("Jogador %s já está em uma batalha", id)
+		}
+	}
+	return nil
+}
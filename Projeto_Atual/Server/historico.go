@@ -0,0 +1,214 @@
+package main
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Participação de um jogador em uma partida do histórico
+type JogadorPartida struct {
+	Id     string   `json:"id"`
+	Equipe int      `json:"equipe"`
+	Deck   []Tanque `json:"deck"`   //Deck no início da batalha
+	Usadas []string `json:"usadas"` //Modelos colocados em campo, na ordem
+	Dano   int      `json:"dano"`
+}
+
+// Partida encerrada guardada no histórico
+type Partida struct {
+	Id         int              `json:"id"`
+	Modo       string           `json:"modo"`
+	Regras     string           `json:"regras"`
+	Jogadores  []JogadorPartida `json:"jogadores"`
+	Turnos     int              `json:"turnos"`
+	Vencedores []string         `json:"vencedores"`
+	Motivo     string           `json:"motivo"`
+	Inicio     time.Time        `json:"inicio"`
+	Duracao    float64          `json:"duracao"` //Em segundos
+}
+
+// Estatísticas de um jogador calculadas a partir do histórico
+type Estatisticas struct {
+	Id             string   `json:"id"`
+	Partidas       int      `json:"partidas"`
+	Vitorias       int      `json:"vitorias"`
+	Derrotas       int      `json:"derrotas"`
+	Empates        int      `json:"empates"`
+	TaxaVitoria    float64  `json:"taxa_vitoria"` //Porcentagem de vitórias
+	TanqueFavorito string   `json:"tanque_favorito"`
+	UsosFavorito   int      `json:"usos_favorito"`
+	MediaTurnos    float64  `json:"media_turnos"`
+	MediaDuracao   float64  `json:"media_duracao"` //Em segundos
+	Conquistas     []string `json:"conquistas"`    //Conquistas desbloqueadas, mostradas no perfil
+}
+
+// Variáveis do histórico
+var (
+	historico      = make([]*Partida, 0) //Partidas encerradas, da mais antiga para a mais recente
+	partidaCounter = 0                   //Contador para gerar ids das partidas
+	muHistorico    sync.RWMutex          //Mutex para sincronizar o histórico
+)
+
+// Quantidade padrão de partidas enviadas no histórico
+const TamanhoHistorico = 10
+
+// Função para guardar no histórico uma batalha encerrada, retornando o id da partida
+func registrarPartida(batalha *Batalha) int {
+	partida := &Partida{
+		Modo:       nomeModo(batalha.Modo),
+		Regras:     batalha.Regras.Nome,
+		Turnos:     batalha.Turnos,
+		Vencedores: batalha.Vencedores,
+		Motivo:     batalha.Motivo,
+		Inicio:     batalha.Inicio,
+		Duracao:    time.Since(batalha.Inicio).Seconds(),
+	}
+	for _, p := range batalha.Participantes {
+		partida.Jogadores = append(partida.Jogadores, JogadorPartida{
+			Id:     p.Id,
+			Equipe: p.Equipe,
+			Deck:   p.DeckInicial,
+			Usadas: p.Usadas,
+			Dano:   p.Dano,
+		})
+	}
+
+	muHistorico.Lock()
+	partidaCounter++
+	partida.Id = partidaCounter
+	historico = append(historico, partida)
+	muHistorico.Unlock()
+
+	return partida.Id
+}
+
+// Função para descrever o modo de batalha em texto
+func nomeModo(modo int) string {
+	switch modo {
+	case ModoEquipes:
+		return "Equipes"
+	case ModoTodosContraTodos:
+		return "Todos contra todos"
+	default:
+		return "Duelo"
+	}
+}
+
+// Função para listar as partidas do jogador, da mais recente para a mais antiga
+func partidasJogador(id string) []Partida {
+	muHistorico.RLock()
+	defer muHistorico.RUnlock()
+
+	partidas := make([]Partida, 0)
+	for i := len(historico) - 1; i >= 0; i-- {
+		for _, j := range historico[i].Jogadores {
+			if j.Id == id {
+				partidas = append(partidas, *historico[i])
+				break
+			}
+		}
+	}
+	return partidas
+}
+
+// Função para verificar se o jogador está entre os vencedores da partida
+func (p Partida) venceu(id string) bool {
+	for _, vencedor := range p.Vencedores {
+		if vencedor == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Função para calcular as estatísticas do jogador a partir das suas partidas
+func calcularEstatisticas(id string) Estatisticas {
+	estatisticas := Estatisticas{Id: id}
+	usos := make(map[string]int)
+	turnos := 0
+	duracao := 0.0
+
+	for _, partida := range partidasJogador(id) {
+		estatisticas.Partidas++
+		turnos += partida.Turnos
+		duracao += partida.Duracao
+
+		switch {
+		case partida.venceu(id):
+			estatisticas.Vitorias++
+		case len(partida.Vencedores) == 0:
+			estatisticas.Empates++
+		default:
+			estatisticas.Derrotas++
+		}
+
+		for _, j := range partida.Jogadores {
+			if j.Id == id {
+				for _, modelo := range j.Usadas {
+					usos[modelo]++
+				}
+			}
+		}
+	}
+
+	if estatisticas.Partidas == 0 {
+		return estatisticas
+	}
+	estatisticas.TaxaVitoria = 100 * float64(estatisticas.Vitorias) / float64(estatisticas.Partidas)
+	estatisticas.MediaTurnos = float64(turnos) / float64(estatisticas.Partidas)
+	estatisticas.MediaDuracao = duracao / float64(estatisticas.Partidas)
+
+	//Tanque mais usado, em caso de empate o primeiro em ordem alfabética
+	modelos := make([]string, 0, len(usos))
+	for modelo := range usos {
+		modelos = append(modelos, modelo)
+	}
+	sort.Strings(modelos)
+	for _, modelo := range modelos {
+		if usos[modelo] > estatisticas.UsosFavorito {
+			estatisticas.TanqueFavorito = modelo
+			estatisticas.UsosFavorito = usos[modelo]
+		}
+	}
+
+	return estatisticas
+}
+
+// Função para enviar as últimas N partidas do jogador
+func enviarHistorico(conn net.Conn, id, mensagem string) {
+	quantidade, err := strconv.Atoi(mensagem)
+	if err != nil || quantidade <= 0 {
+		quantidade = TamanhoHistorico
+	}
+
+	partidas := partidasJogador(id)
+	total := len(partidas)
+	if total > quantidade {
+		partidas = partidas[:quantidade]
+	}
+
+	resposta := Resposta{
+		Tipo:     "Historico",
+		Mensagem: strconv.Itoa(total),
+		Partidas: partidas,
+	}
+	enviarResposta(conn, resposta)
+}
+
+// Função para enviar as estatísticas do jogador informado na mensagem, ou do próprio remetente
+func enviarEstatisticas(conn net.Conn, id, mensagem string) {
+	if mensagem != "" && mensagem != "None" {
+		id = mensagem
+	}
+
+	estatisticas := calcularEstatisticas(id)
+	estatisticas.Conquistas = conquistasJogador(id)
+	resposta := Resposta{Tipo: "Estatisticas", Estatisticas: &estatisticas}
+	if estatisticas.Partidas == 0 {
+		resposta.Mensagem = "Jogador " + id + " ainda não tem partidas registradas"
+	}
+	enviarResposta(conn, resposta)
+}
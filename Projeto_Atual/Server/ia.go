@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Dificuldade do bot, com a estratégia de escolha das cartas e as classes do deck
+type Dificuldade struct {
+	Nome       string
+	Estrategia string   //"Aleatoria", "Gulosa" ou "Antecipacao"
+	Classes    []string //Classes de tanque sorteadas para o deck do bot
+	Espera     time.Duration
+}
+
+// Dificuldades do bot, da mais fácil para a mais difícil
+var dificuldades = []Dificuldade{
+	{Nome: "Facil", Estrategia: "Aleatoria", Classes: []string{"(Light)", "(Medium)"}, Espera: 1 * time.Second},
+	{Nome: "Medio", Estrategia: "Gulosa", Classes: []string{"(Light)", "(Medium)", "(Heavy)"}, Espera: 1 * time.Second},
+	{Nome: "Dificil", Estrategia: "Antecipacao", Classes: []string{"(Medium)", "(Heavy)"}, Espera: 500 * time.Millisecond},
+}
+
+// Prefixo dos ids dos bots, que nunca colidem com os ids numéricos dos jogadores
+const PrefixoIA = "IA-"
+
+// Variáveis dos bots
+var (
+	iaCounter int                         //Contador do ID dos bots
+	bots      = make(map[string]net.Conn) //Conexão em memória de cada bot em batalha, fora do map de clientes
+	muIA      sync.Mutex                  //Mutex para sincronizar o contador e as conexões dos bots
+)
+
+// Função para buscar a conexão do bot em batalha pelo id
+func conexaoIA(id string) (net.Conn, bool) {
+	muIA.Lock()
+	defer muIA.Unlock()
+	conn, existe := bots[id]
+	return conn, existe
+}
+
+// Função para verificar se o id é de um bot do servidor
+func ehIA(id string) bool {
+	return strings.HasPrefix(id, PrefixoIA)
+}
+
+// Função para buscar a dificuldade pelo nome, sem diferenciar maiúsculas. Vazio usa a média
+func buscarDificuldade(nome string) (Dificuldade, bool) {
+	if nome == "" || nome == "None" {
+		return dificuldades[1], true
+	}
+	for _, d := range dificuldades {
+		if strings.EqualFold(d.Nome, nome) {
+			return d, true
+		}
+	}
+	return Dificuldade{}, false
+}
+
+// Função para iniciar uma batalha contra o bot a partir da mensagem "<dificuldade> [regras]"
+func iniciarBatalhaIA(conn net.Conn, id, mensagem string) {
+	var resposta Resposta
+
+	campos := strings.Fields(mensagem)
+	nomeDificuldade, nomeRegras := "", ""
+	if len(campos) > 0 {
+		nomeDificuldade = campos[0]
+	}
+	if len(campos) > 1 {
+		nomeRegras = campos[1]
+	}
+
+	dificuldade, ok := buscarDificuldade(nomeDificuldade)
+	if !ok {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Dificuldade %s não existe, use Facil, Medio ou Dificil", nomeDificuldade)
+		enviarResposta(conn, resposta)
+		return
+	}
+	regras, ok := buscarRegras(nomeRegras)
+	if !ok {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Regras de batalha %s não existem", nomeRegras)
+		enviarResposta(conn, resposta)
+		return
+	}
+	if emBatalha(id) {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Já existe uma batalha em andamento"
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	batalha, err := novaBatalhaIA(id, regras, sortearDeckIA(dificuldade, regras.TamanhoDeck), nil)
+	if err != nil {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+	go realizarBatalhaIA(batalha, dificuldade)
+}
+
+// Função para sortear o deck do bot do catálogo com as classes da dificuldade, com cartas temporárias
+func sortearDeckIA(dificuldade Dificuldade, tamanho int) []Tanque {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	catalogo := filtrarCartas(pacote_1, dificuldade.Classes...)
+	deck := make([]Tanque, 0, tamanho)
+	for i := 0; i < tamanho; i++ {
+		m := catalogo[r.Intn(len(catalogo))]
+		deck = append(deck, Tanque{Modelo: m.Modelo, Vida: m.Vida, Ataque: m.Ataque})
+	}
+	return deck
+}
+
+// Função para criar o id do bot e registrar a batalha com o deck informado, sem iniciar.
+// O deck do jogador pode ser trocado, nil usa o deck normal da batalha
+func novaBatalhaIA(id string, regras Regras, deckIA, deckJogador []Tanque) (*Batalha, error) {
+	muIA.Lock()
+	iaCounter++
+	idIA := fmt.Sprintf("%s%d", PrefixoIA, iaCounter)
+	muIA.Unlock()
+
+	for i := range deckIA {
+		deckIA[i].Id_jogador = idIA
+	}
+	decks := map[string][]Tanque{idIA: deckIA}
+	if deckJogador != nil {
+		decks[id] = deckJogador
+	}
+
+	batalha, err := novaBatalha(ModoDuelo, []string{id, idIA}, regras, decks, nil)
+	if err != nil {
+		return nil, err
+	}
+	batalha.ContraIA = true
+	return batalha, nil
+}
+
+// Função para conectar o bot, realizar a batalha e desconectar o bot no fim.
+// O bot recebe as mensagens da batalha por uma conexão em memória, como um jogador conectado
+func realizarBatalhaIA(batalha *Batalha, dificuldade Dificuldade) {
+	id, idIA := batalha.Participantes[0].Id, batalha.Participantes[1].Id
+
+	//Bot fora do map de clientes, só a batalha envia mensagens a ele
+	servidor, bot := net.Pipe()
+	muIA.Lock()
+	bots[idIA] = servidor
+	muIA.Unlock()
+	go jogarIA(idIA, servidor, bot, dificuldade)
+
+	color.Cyan("Jogador %s desafiou o bot %s (%s)", id, idIA, dificuldade.Nome)
+	realizarBatalha(batalha)
+
+	muIA.Lock()
+	delete(bots, idIA)
+	muIA.Unlock()
+	servidor.Close()
+}
+
+// Função que faz o papel do cliente do bot: lê as respostas do servidor e escolhe as cartas pela estratégia
+func jogarIA(id string, servidor, bot net.Conn, dificuldade Dificuldade) {
+	//Leitura separada das escolhas, a conexão em memória só libera a escrita quando o outro lado lê
+	respostas := make(chan Resposta, 64)
+	go func() {
+		defer close(respostas)
+		decoder := json.NewDecoder(bot)
+		for {
+			var resposta Resposta
+			if err := decoder.Decode(&resposta); err != nil {
+				return
+			}
+			respostas <- resposta
+		}
+	}()
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for resposta := range respostas {
+		switch resposta.Tipo {
+		case "Enviar_Próxima_Carta":
+			time.Sleep(dificuldade.Espera) //Pausa para a batalha não ficar instantânea para o jogador
+			indice := escolherCartaIA(id, resposta.Cartas, dificuldade.Estrategia, r)
+			receberEscolha(servidor, id, "Próxima_Carta", strconv.Itoa(indice))
+
+		case "Escolher_Alvo":
+			receberEscolha(servidor, id, "Alvo", "0")
+		}
+	}
+}
+
+// Função para escolher o índice da carta do deck pela estratégia do bot
+func escolherCartaIA(id string, deck []Tanque, estrategia string, r *rand.Rand) int {
+	if len(deck) <= 1 || estrategia == "Aleatoria" {
+		return r.Intn(max(len(deck), 1))
+	}
+
+	//Carta e deck restante do oponente, a batalha está parada esperando a escolha do bot
+	muBatalhas.RLock()
+	batalha, existe := batalhas[id]
+	muBatalhas.RUnlock()
+	if !existe {
+		return 0
+	}
+	var oponente *Participante
+	for _, p := range batalha.Participantes {
+		if p.Id != id {
+			oponente = p
+		}
+	}
+	var cartaOponente *Tanque
+	if oponente.Carta != nil {
+		copia := *oponente.Carta
+		cartaOponente = &copia
+	}
+
+	if estrategia == "Gulosa" {
+		return escolhaGulosa(deck, cartaOponente)
+	}
+
+	//Antecipação: simula o resto da batalha para cada carta e fica com o melhor saldo de vida
+	proximoIA := batalha.Participantes[batalha.Turnos%2].Id == id
+	melhor, melhorSaldo := 0, 0
+	for i := range deck {
+		restante := append(append([]Tanque(nil), deck[:i]...), deck[i+1:]...)
+		carta := deck[i]
+		saldo := simularDuelo(&carta, restante, cartaOponente, append([]Tanque(nil), oponente.Deck...), proximoIA)
+		if i == 0 || saldo > melhorSaldo {
+			melhor, melhorSaldo = i, saldo
+		}
+	}
+	return melhor
+}
+
+// Função para a escolha gulosa: a carta que destrói o tanque do oponente e sobra com mais vida,
+// ou a de maior ataque quando nenhuma destrói
+func escolhaGulosa(deck []Tanque, cartaOponente *Tanque) int {
+	pontuacao := func(carta Tanque) int {
+		if cartaOponente != nil && carta.Ataque >= cartaOponente.Vida {
+			return 1000000 + carta.Vida
+		}
+		return carta.Ataque
+	}
+
+	melhor := 0
+	for i, carta := range deck {
+		if pontuacao(carta) > pontuacao(deck[melhor]) {
+			melhor = i
+		}
+	}
+	return melhor
+}
+
+// Função para simular o duelo até um lado ficar sem cartas, os dois escolhendo de forma gulosa.
+// Retorna a vida total que sobra para o bot menos a que sobra para o oponente, com bônus de vitória
+func simularDuelo(carta *Tanque, deck []Tanque, cartaOponente *Tanque, deckOponente []Tanque, vezIA bool) int {
+	for turno := 0; turno < 200; turno++ {
+		if carta == nil && len(deck) > 0 {
+			i := escolhaGulosa(deck, cartaOponente)
+			proxima := deck[i]
+			carta = &proxima
+			deck = append(deck[:i:i], deck[i+1:]...)
+		}
+		if cartaOponente == nil && len(deckOponente) > 0 {
+			i := escolhaGulosa(deckOponente, carta)
+			proxima := deckOponente[i]
+			cartaOponente = &proxima
+			deckOponente = append(deckOponente[:i:i], deckOponente[i+1:]...)
+		}
+		if carta == nil || cartaOponente == nil {
+			break
+		}
+
+		if vezIA {
+			cartaOponente.Vida -= carta.Ataque
+			if cartaOponente.Vida <= 0 {
+				cartaOponente = nil
+			}
+		} else {
+			carta.Vida -= cartaOponente.Ataque
+			if carta.Vida <= 0 {
+				carta = nil
+			}
+		}
+		vezIA = !vezIA
+	}
+
+	saldo := vidaTotal(carta, deck) - vidaTotal(cartaOponente, deckOponente)
+	if carta != nil && cartaOponente == nil {
+		saldo += 10000
+	} else if carta == nil && cartaOponente != nil {
+		saldo -= 10000
+	}
+	return saldo
+}
+
+// Função para somar a vida da carta em campo e das cartas restantes
+func vidaTotal(carta *Tanque, deck []Tanque) int {
+	total := 0
+	if carta != nil {
+		total += carta.Vida
+	}
+	for _, c := range deck {
+		total += c.Vida
+	}
+	return total
+}
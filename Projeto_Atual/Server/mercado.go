@@ -0,0 +1,341 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Anúncio de uma carta no mercado, vendida por preço fixo ou em leilão.
+// A carta fica guardada no anúncio e os lances ficam descontados do saldo até a liquidação
+type Anuncio struct {
+	Id        int       `json:"id"`
+	Vendedor  string    `json:"vendedor"`
+	Carta     Tanque    `json:"carta"`
+	Preco     int       `json:"preco"` //Preço fixo ou lance mínimo do leilão
+	Leilao    bool      `json:"leilao"`
+	Lance     int       `json:"lance"`     //Maior lance atual
+	Licitante string    `json:"licitante"` //Jogador do maior lance
+	Fim       time.Time `json:"fim"`
+}
+
+// Variáveis do mercado
+var (
+	anuncios       = make(map[int]*Anuncio) //Anúncios abertos pelo id
+	anuncioCounter int                      //Contador para gerar ids dos anúncios
+	muAnuncios     sync.Mutex               //Mutex para sincronizar os anúncios, bloqueado antes de muSaldos e muColecoes
+)
+
+// Constantes do mercado
+const (
+	DuracaoVenda       = 10 * time.Minute //Tempo de um anúncio de preço fixo
+	DuracaoMinimaLance = 10 * time.Second
+	DuracaoMaximaLance = 1 * time.Hour
+	IntervaloMercado   = 1 * time.Second //Intervalo da liquidação dos anúncios vencidos
+	MaxAnuncios        = 10              //Anúncios abertos por vendedor
+)
+
+// Função para anunciar uma carta da coleção. A mensagem tem o preço, ou o lance mínimo e a duração em segundos para leilão
+func anunciarCarta(conn net.Conn, id, mensagem string, cartas []Tanque) {
+	var resposta Resposta
+
+	campos := strings.Fields(mensagem)
+	if len(campos) == 0 || len(campos) > 2 {
+		campos = []string{""}
+	}
+	preco, err := strconv.Atoi(campos[0])
+	if err != nil || preco <= 0 {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Use <preço> para venda ou <lance mínimo> <segundos> para leilão"
+		enviarResposta(conn, resposta)
+		return
+	}
+	if len(cartas) != 1 {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Cada anúncio deve ter exatamente uma carta"
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	anuncio := &Anuncio{Vendedor: id, Preco: preco, Fim: time.Now().Add(DuracaoVenda)}
+	if len(campos) == 2 {
+		segundos, err := strconv.Atoi(campos[1])
+		duracao := time.Duration(segundos) * time.Second
+		if err != nil || duracao < DuracaoMinimaLance || duracao > DuracaoMaximaLance {
+			resposta.Tipo = "Erro"
+			resposta.Mensagem = fmt.Sprintf("Duração do leilão deve ficar entre %d e %d segundos", int(DuracaoMinimaLance.Seconds()), int(DuracaoMaximaLance.Seconds()))
+			enviarResposta(conn, resposta)
+			return
+		}
+		anuncio.Leilao = true
+		anuncio.Fim = time.Now().Add(duracao)
+	}
+
+	muAnuncios.Lock()
+	defer muAnuncios.Unlock()
+
+	abertos := 0
+	for _, a := range anuncios {
+		if a.Vendedor == id {
+			abertos++
+		}
+	}
+	if abertos >= MaxAnuncios {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Você já tem %d anúncios abertos", MaxAnuncios)
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	//Carta sai da coleção e fica presa no anúncio, não pode ser usada em decks ou trocas
+	muColecoes.Lock()
+	posicoes, err := encontrarNaColecao(colecoes[id], cartas)
+	if err != nil {
+		muColecoes.Unlock()
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+	anuncio.Carta = transferirCartas(id, posicoes, id)[0]
+	colecao := append([]Tanque{}, colecoes[id]...)
+	muColecoes.Unlock()
+
+	anuncioCounter++
+	anuncio.Id = anuncioCounter
+	anuncios[anuncio.Id] = anuncio
+
+	resposta.Tipo = "Mercado"
+	resposta.Mensagem = fmt.Sprintf("Anúncio %d criado: %s", anuncio.Id, descreverAnuncio(anuncio))
+	resposta.Cartas = colecao
+	enviarResposta(conn, resposta)
+
+	//Log do servidor
+	color.Cyan("Jogador %s anunciou %s no anúncio %d", id, anuncio.Carta.Modelo, anuncio.Id)
+}
+
+// Função para listar os anúncios abertos ordenados pelo id
+func listarAnuncios(conn net.Conn) {
+	muAnuncios.Lock()
+	lista := make([]Anuncio, 0, len(anuncios))
+	for _, anuncio := range anuncios {
+		lista = append(lista, *anuncio)
+	}
+	muAnuncios.Unlock()
+
+	sort.Slice(lista, func(i, j int) bool { return lista[i].Id < lista[j].Id })
+	enviarResposta(conn, Resposta{Tipo: "Lista_Anuncios", Anuncios: lista})
+}
+
+// Função para comprar pelo preço fixo, trocando moedas e carta de uma só vez
+func comprarAnuncio(conn net.Conn, id, mensagem string) {
+	var resposta Resposta
+
+	muAnuncios.Lock()
+	defer muAnuncios.Unlock()
+
+	anuncio, existe := buscarAnuncio(mensagem)
+	if !existe || anuncio.Leilao {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Anúncio de venda não encontrado"
+		enviarResposta(conn, resposta)
+		return
+	}
+	if anuncio.Vendedor == id {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Você não pode comprar o próprio anúncio"
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	muSaldos.Lock()
+	if saldos[id] < anuncio.Preco {
+		saldo := saldos[id]
+		muSaldos.Unlock()
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Saldo insuficiente: o anúncio custa %d moedas e você tem %d", anuncio.Preco, saldo)
+		enviarResposta(conn, resposta)
+		return
+	}
+	saldos[id] -= anuncio.Preco
+	saldos[anuncio.Vendedor] += anuncio.Preco
+	muSaldos.Unlock()
+
+	delete(anuncios, anuncio.Id)
+	entregarAnuncio(anuncio, id, anuncio.Preco)
+}
+
+// Função para dar um lance no leilão. A mensagem tem o id do anúncio e o valor.
+// O valor fica descontado do saldo e o lance anterior é devolvido
+func darLance(conn net.Conn, id, mensagem string) {
+	var resposta Resposta
+
+	campos := strings.Fields(mensagem)
+	if len(campos) != 2 {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Use <id do anúncio> <valor>"
+		enviarResposta(conn, resposta)
+		return
+	}
+	valor, err := strconv.Atoi(campos[1])
+
+	muAnuncios.Lock()
+	defer muAnuncios.Unlock()
+
+	anuncio, existe := buscarAnuncio(campos[0])
+	if !existe || !anuncio.Leilao || time.Now().After(anuncio.Fim) {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Leilão não encontrado ou já encerrado"
+		enviarResposta(conn, resposta)
+		return
+	}
+	if anuncio.Vendedor == id {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Você não pode dar lance no próprio leilão"
+		enviarResposta(conn, resposta)
+		return
+	}
+	minimo := max(anuncio.Preco, anuncio.Lance+1)
+	if err != nil || valor < minimo {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("O lance mínimo é %d moedas", minimo)
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	muSaldos.Lock()
+	disponivel := saldos[id]
+	if anuncio.Licitante == id {
+		disponivel += anuncio.Lance //Quem cobre o próprio lance só paga a diferença
+	}
+	if disponivel < valor {
+		muSaldos.Unlock()
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Saldo insuficiente: o lance é de %d moedas e você tem %d", valor, disponivel)
+		enviarResposta(conn, resposta)
+		return
+	}
+	anterior, lanceAnterior := anuncio.Licitante, anuncio.Lance
+	if anterior != "" {
+		saldos[anterior] += lanceAnterior
+	}
+	saldos[id] -= valor
+	saldo := saldos[id]
+	muSaldos.Unlock()
+
+	anuncio.Licitante = id
+	anuncio.Lance = valor
+
+	resposta.Tipo = "Mercado"
+	resposta.Mensagem = fmt.Sprintf("Lance de %d moedas registrado no leilão %d. Saldo: %d moedas", valor, anuncio.Id, saldo)
+	enviarResposta(conn, resposta)
+
+	if anterior != "" && anterior != id {
+		aviso := Resposta{Tipo: "Mercado", Mensagem: fmt.Sprintf("Seu lance no leilão %d foi superado, %d moedas devolvidas", anuncio.Id, lanceAnterior)}
+		enviarParaJogadores(aviso, anterior)
+	}
+	aviso := Resposta{Tipo: "Mercado", Mensagem: fmt.Sprintf("Novo lance de %d moedas no seu leilão %d", valor, anuncio.Id)}
+	enviarParaJogadores(aviso, anuncio.Vendedor)
+}
+
+// Função para o vendedor retirar um anúncio que ainda não tem lances
+func retirarAnuncio(conn net.Conn, id, mensagem string) {
+	muAnuncios.Lock()
+	defer muAnuncios.Unlock()
+
+	anuncio, existe := buscarAnuncio(mensagem)
+	if !existe || anuncio.Vendedor != id {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: "Anúncio não encontrado entre os seus"})
+		return
+	}
+	if anuncio.Licitante != "" {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: "Leilão com lances não pode ser retirado"})
+		return
+	}
+	delete(anuncios, anuncio.Id)
+	entregarAnuncio(anuncio, "", 0)
+}
+
+// Função da goroutine que liquida os anúncios vencidos
+func liquidarAnuncios() {
+	ticker := time.NewTicker(IntervaloMercado)
+	defer ticker.Stop()
+
+	for agora := range ticker.C {
+		muAnuncios.Lock()
+		for idAnuncio, anuncio := range anuncios {
+			if agora.Before(anuncio.Fim) {
+				continue
+			}
+			delete(anuncios, idAnuncio)
+
+			//Lance vencedor já está descontado do comprador, só falta pagar o vendedor
+			if anuncio.Licitante != "" {
+				creditarMoedas(anuncio.Vendedor, anuncio.Lance)
+			}
+			entregarAnuncio(anuncio, anuncio.Licitante, anuncio.Lance)
+		}
+		muAnuncios.Unlock()
+	}
+}
+
+// Função para entregar a carta do anúncio encerrado ao comprador, ou devolver ao vendedor se não houve comprador.
+// Deve ser chamada com muAnuncios bloqueado e com as moedas já transferidas
+func entregarAnuncio(anuncio *Anuncio, comprador string, valor int) {
+	dono := comprador
+	if dono == "" {
+		dono = anuncio.Vendedor
+	}
+	carta := anuncio.Carta
+	carta.Id_jogador = dono
+
+	muColecoes.Lock()
+	colecoes[dono] = append(colecoes[dono], carta)
+	colecaoDono := append([]Tanque{}, colecoes[dono]...)
+	muColecoes.Unlock()
+
+	if comprador == "" {
+		resposta := Resposta{Tipo: "Mercado", Mensagem: fmt.Sprintf("Anúncio %d encerrado sem comprador, %s voltou para a sua coleção", anuncio.Id, carta.Modelo), Cartas: colecaoDono}
+		enviarParaJogadores(resposta, anuncio.Vendedor)
+
+		//Log do servidor
+		color.Yellow("Anúncio %d de %s encerrado sem comprador", anuncio.Id, anuncio.Vendedor)
+		return
+	}
+
+	registrarPosse([]Tanque{carta}, comprador, fmt.Sprintf("Mercado, anúncio %d por %d moedas", anuncio.Id, valor))
+
+	resposta := Resposta{Tipo: "Mercado", Mensagem: fmt.Sprintf("Você comprou %s do anúncio %d por %d moedas", carta.Modelo, anuncio.Id, valor), Cartas: colecaoDono}
+	enviarParaJogadores(resposta, comprador)
+	resposta = Resposta{Tipo: "Mercado", Mensagem: fmt.Sprintf("Seu anúncio %d (%s) foi vendido para %s por %d moedas. Saldo: %d moedas", anuncio.Id, carta.Modelo, comprador, valor, saldoJogador(anuncio.Vendedor))}
+	enviarParaJogadores(resposta, anuncio.Vendedor)
+	registrarEventoMissao(comprador, "Negocio", 1)
+	registrarEventoMissao(anuncio.Vendedor, "Negocio", 1)
+
+	//Log do servidor
+	color.Green("Anúncio %d de %s vendido para %s por %d moedas", anuncio.Id, anuncio.Vendedor, comprador, valor)
+}
+
+// Função para buscar o anúncio pelo id em texto. Deve ser chamada com muAnuncios bloqueado
+func buscarAnuncio(mensagem string) (*Anuncio, bool) {
+	idAnuncio, err := strconv.Atoi(strings.TrimSpace(mensagem))
+	if err != nil {
+		return nil, false
+	}
+	anuncio, existe := anuncios[idAnuncio]
+	return anuncio, existe
+}
+
+// Função para descrever o anúncio em uma linha
+func descreverAnuncio(a *Anuncio) string {
+	if a.Leilao {
+		return fmt.Sprintf("leilão de %s com lance mínimo de %d moedas até %s", a.Carta.Modelo, a.Preco, a.Fim.Format("15:04:05"))
+	}
+	return fmt.Sprintf("%s por %d moedas", a.Carta.Modelo, a.Preco)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Função para criar uma conexão em memória que descarta tudo que o servidor envia
+func conexaoDescartada(t *testing.T) net.Conn {
+	servidor, cliente := net.Pipe()
+	go io.Copy(io.Discard, cliente)
+	t.Cleanup(func() { servidor.Close() })
+	return servidor
+}
+
+// Função para preparar o mercado com um único anúncio do vendedor "v" e os saldos dos jogadores
+func prepararMercado(anuncio *Anuncio, jogadores, saldo int) {
+	anuncio.Id, anuncio.Vendedor = 1, "v"
+	anuncio.Carta = Tanque{Modelo: "A", Id_jogador: "v", Vida: 10, Ataque: 5}
+	anuncio.Fim = time.Now().Add(time.Minute)
+	anuncios = map[int]*Anuncio{1: anuncio}
+	colecoes = make(map[string][]Tanque)
+	saldos = make(map[string]int)
+	for i := 0; i < jogadores; i++ {
+		saldos[strconv.Itoa(i)] = saldo
+	}
+
+	//Sem missões no dia, as recompensas da venda não entram nas contas dos testes
+	missoesDiarias = make(map[string]*MissoesDiarias)
+	dia := time.Now().Format("2006-01-02")
+	for id := range saldos {
+		missoesDiarias[id] = &MissoesDiarias{Dia: dia}
+	}
+	missoesDiarias["v"] = &MissoesDiarias{Dia: dia}
+}
+
+func TestLancesSimultaneosConservamMoedas(t *testing.T) {
+	const licitantes, saldo = 20, 1000
+	prepararMercado(&Anuncio{Preco: 10, Leilao: true}, licitantes, saldo)
+
+	var wg sync.WaitGroup
+	for i := 0; i < licitantes; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			conn := conexaoDescartada(t)
+			for valor := 10; valor <= 300; valor += 10 {
+				darLance(conn, id, "1 "+strconv.Itoa(valor))
+			}
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	//Só o maior lance fica preso no anúncio, os superados voltam inteiros
+	anuncio := anuncios[1]
+	if anuncio.Lance != 300 || anuncio.Licitante == "" {
+		t.Fatalf("lance final %d de %q, esperado 300", anuncio.Lance, anuncio.Licitante)
+	}
+	for id, s := range saldos {
+		esperado := saldo
+		if id == anuncio.Licitante {
+			esperado -= anuncio.Lance
+		}
+		if id == "v" {
+			esperado = 0
+		}
+		if s != esperado {
+			t.Errorf("jogador %s com saldo %d, esperado %d", id, s, esperado)
+		}
+	}
+}
+
+func TestCompraSimultaneaEntregaUmaVez(t *testing.T) {
+	const compradores, preco = 10, 100
+	prepararMercado(&Anuncio{Preco: preco}, compradores, preco)
+
+	var wg sync.WaitGroup
+	for i := 0; i < compradores; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			comprarAnuncio(conexaoDescartada(t), id, "1")
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	if len(anuncios) != 0 {
+		t.Error("anúncio vendido continua aberto")
+	}
+	if saldos["v"] != preco {
+		t.Errorf("vendedor recebeu %d moedas, esperado %d", saldos["v"], preco)
+	}
+	donos, pago := 0, 0
+	for i := 0; i < compradores; i++ {
+		id := strconv.Itoa(i)
+		pago += preco - saldos[id]
+		switch len(colecoes[id]) {
+		case 0:
+			if saldos[id] != preco {
+				t.Errorf("jogador %s sem a carta pagou %d moedas", id, preco-saldos[id])
+			}
+		case 1:
+			donos++
+			if saldos[id] != 0 || colecoes[id][0].Id_jogador != id {
+				t.Errorf("comprador %s com saldo %d e carta de %s", id, saldos[id], colecoes[id][0].Id_jogador)
+			}
+		default:
+			t.Errorf("jogador %s recebeu %d cartas", id, len(colecoes[id]))
+		}
+	}
+	if donos != 1 || pago != preco {
+		t.Errorf("%d jogadores receberam a carta pagando %d moedas, esperado 1 e %d", donos, pago, preco)
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Objetivo de missão diária ou conquista, completado ao acumular a meta de um evento do servidor
+type Objetivo struct {
+	Nome      string
+	Descricao string
+	Evento    string //Evento que conta progresso, ex: "Vitoria", "Dano_Light"
+	Meta      int
+	Moedas    int    //Recompensa em moedas
+	Pacote    string //Recompensa em pacote, vazio se não houver
+}
+
+// Progresso de uma missão ou conquista enviado ao cliente
+type ProgressoMissao struct {
+	Nome       string `json:"nome"`
+	Descricao  string `json:"descricao"`
+	Progresso  int    `json:"progresso"`
+	Meta       int    `json:"meta"`
+	Recompensa string `json:"recompensa"`
+	Concluida  bool   `json:"concluida"`
+}
+
+// Missões diárias sorteadas para o jogador
+type MissoesDiarias struct {
+	Dia       string
+	Objetivos []Objetivo
+	Progresso []int
+}
+
+// Variáveis das missões
+var (
+	missoesDiarias = make(map[string]*MissoesDiarias)      //Missões do dia de cada jogador
+	contadores     = make(map[string]map[string]int)       //Total de cada evento por jogador, usado nas conquistas
+	conquistas     = make(map[string]map[string]time.Time) //Conquistas desbloqueadas por jogador com a data
+	muMissoes      sync.Mutex                              //Mutex para sincronizar missões e conquistas
+)
+
+// Quantidade de missões sorteadas por dia
+const MissoesPorDia = 3
+
+// Missões que podem ser sorteadas no dia
+var objetivosDiarios = []Objetivo{
+	{Nome: "Vencedor", Descricao: "Vencer 3 batalhas", Evento: "Vitoria", Meta: 3, Moedas: 100},
+	{Nome: "Cavalaria leve", Descricao: "Causar 500 de dano com tanques leves", Evento: "Dano_Light", Meta: 500, Moedas: 80},
+	{Nome: "Bom de papo", Descricao: "Conversar com um oponente", Evento: "Mensagem", Meta: 1, Moedas: 30},
+	{Nome: "Combatente", Descricao: "Jogar 5 batalhas", Evento: "Batalha", Meta: 5, Pacote: "Basico"},
+	{Nome: "Artilharia pesada", Descricao: "Causar 800 de dano com tanques pesados", Evento: "Dano_Heavy", Meta: 800, Moedas: 80},
+	{Nome: "Comprador", Descricao: "Abrir 1 pacote", Evento: "Pacote", Meta: 1, Moedas: 20},
+	{Nome: "Negociante", Descricao: "Concluir 1 troca ou compra no mercado", Evento: "Negocio", Meta: 1, Moedas: 40},
+}
+
+// Conquistas permanentes, mostradas no perfil
+var objetivosConquistas = []Objetivo{
+	{Nome: "Primeira vitória", Descricao: "Vencer uma batalha", Evento: "Vitoria", Meta: 1},
+	{Nome: "Veterano", Descricao: "Jogar 50 batalhas", Evento: "Batalha", Meta: 50},
+	{Nome: "Campeão", Descricao: "Vencer 25 batalhas", Evento: "Vitoria", Meta: 25},
+	{Nome: "Destruidor", Descricao: "Causar 10000 de dano", Evento: "Dano", Meta: 10000},
+	{Nome: "Colecionador", Descricao: "Abrir 10 pacotes", Evento: "Pacote", Meta: 10},
+	{Nome: "Ferreiro", Descricao: "Fundir ou fabricar uma carta", Evento: "Forja", Meta: 1},
+	{Nome: "Magnata", Descricao: "Concluir 10 trocas ou compras no mercado", Evento: "Negocio", Meta: 10},
+	{Nome: "Diplomata", Descricao: "Conversar com oponentes 20 vezes", Evento: "Mensagem", Meta: 20},
+}
+
+// Função para sortear as missões do dia, sempre as mesmas para o mesmo jogador no mesmo dia
+func sortearMissoes(id, dia string) *MissoesDiarias {
+	hash := fnv.New64a()
+	hash.Write([]byte(id + "|" + dia))
+	r := rand.New(rand.NewSource(int64(hash.Sum64())))
+
+	missoes := &MissoesDiarias{Dia: dia, Progresso: make([]int, MissoesPorDia)}
+	for _, i := range r.Perm(len(objetivosDiarios))[:MissoesPorDia] {
+		missoes.Objetivos = append(missoes.Objetivos, objetivosDiarios[i])
+	}
+	return missoes
+}
+
+// Função para pegar as missões do dia do jogador, sorteando novas quando o dia muda.
+// Deve ser chamada com muMissoes bloqueado
+func missoesDoDia(id string) *MissoesDiarias {
+	dia := time.Now().Format("2006-01-02")
+	missoes, existe := missoesDiarias[id]
+	if !existe || missoes.Dia != dia {
+		missoes = sortearMissoes(id, dia)
+		missoesDiarias[id] = missoes
+	}
+	return missoes
+}
+
+// Função para registrar um evento do jogador, avançando missões e conquistas.
+// Missões concluídas pagam a recompensa na hora
+func registrarEventoMissao(id, evento string, quantidade int) {
+	if quantidade <= 0 || ehIA(id) {
+		return
+	}
+
+	concluidas := make([]Objetivo, 0)
+	desbloqueadas := make([]Objetivo, 0)
+
+	muMissoes.Lock()
+	missoes := missoesDoDia(id)
+	for i, objetivo := range missoes.Objetivos {
+		if objetivo.Evento != evento || missoes.Progresso[i] >= objetivo.Meta {
+			continue
+		}
+		missoes.Progresso[i] = min(missoes.Progresso[i]+quantidade, objetivo.Meta)
+		if missoes.Progresso[i] == objetivo.Meta {
+			concluidas = append(concluidas, objetivo)
+		}
+	}
+
+	if contadores[id] == nil {
+		contadores[id] = make(map[string]int)
+		conquistas[id] = make(map[string]time.Time)
+	}
+	contadores[id][evento] += quantidade
+	for _, objetivo := range objetivosConquistas {
+		if _, desbloqueada := conquistas[id][objetivo.Nome]; !desbloqueada && objetivo.Evento == evento && contadores[id][evento] >= objetivo.Meta {
+			conquistas[id][objetivo.Nome] = time.Now()
+			desbloqueadas = append(desbloqueadas, objetivo)
+		}
+	}
+	muMissoes.Unlock()
+
+	for _, objetivo := range concluidas {
+		pagarRecompensa(id, objetivo)
+	}
+	for _, objetivo := range desbloqueadas {
+		resposta := Resposta{Tipo: "Conquista_Desbloqueada", Mensagem: fmt.Sprintf("Conquista desbloqueada: %s (%s)", objetivo.Nome, objetivo.Descricao)}
+		enviarParaJogadores(resposta, id)
+
+		//Log do servidor
+		color.Green("Jogador %s desbloqueou a conquista %s", id, objetivo.Nome)
+	}
+}
+
+// Função para registrar o dano causado por uma carta, contando também pela classe do tanque
+func registrarDanoMissao(id string, carta Tanque, dano int) {
+	registrarEventoMissao(id, "Dano", dano)
+	if raridade, existe := raridadeDoModelo(carta.Modelo); existe {
+		classe := strings.Trim(raridade.Classe, "()")
+		registrarEventoMissao(id, "Dano_"+classe, dano)
+	}
+}
+
+// Função para pagar a recompensa da missão concluída e avisar o jogador
+func pagarRecompensa(id string, objetivo Objetivo) {
+	resposta := Resposta{Tipo: "Missao_Concluida"}
+	if objetivo.Moedas > 0 {
+		saldo := creditarMoedas(id, objetivo.Moedas)
+		resposta.Mensagem = fmt.Sprintf("Missão %s concluída! Você ganhou %d moedas. Saldo: %d moedas", objetivo.Nome, objetivo.Moedas, saldo)
+	}
+
+	//Pacote de recompensa não sai do estoque da loja
+	if tipo, existe := buscarTipoPacote(objetivo.Pacote); existe && objetivo.Pacote != "" {
+		cartas := sortearDoPacote(tipo, id)
+		muColecoes.Lock()
+		colecoes[id] = append(colecoes[id], cartas...)
+		muColecoes.Unlock()
+		resposta.Mensagem = fmt.Sprintf("Missão %s concluída! Você ganhou um pacote %s", objetivo.Nome, tipo.Nome)
+		resposta.Cartas = cartas
+	}
+	enviarParaJogadores(resposta, id)
+
+	//Log do servidor
+	color.Green("Jogador %s concluiu a missão %s", id, objetivo.Nome)
+}
+
+// Função para descrever a recompensa do objetivo
+func descreverRecompensa(objetivo Objetivo) string {
+	if objetivo.Pacote != "" {
+		return "Pacote " + objetivo.Pacote
+	}
+	if objetivo.Moedas > 0 {
+		return fmt.Sprintf("%d moedas", objetivo.Moedas)
+	}
+	return ""
+}
+
+// Função para enviar o progresso das missões do dia
+func enviarMissoes(conn net.Conn, id string) {
+	muMissoes.Lock()
+	missoes := missoesDoDia(id)
+	lista := make([]ProgressoMissao, 0, len(missoes.Objetivos))
+	for i, objetivo := range missoes.Objetivos {
+		lista = append(lista, ProgressoMissao{
+			Nome:       objetivo.Nome,
+			Descricao:  objetivo.Descricao,
+			Progresso:  missoes.Progresso[i],
+			Meta:       objetivo.Meta,
+			Recompensa: descreverRecompensa(objetivo),
+			Concluida:  missoes.Progresso[i] >= objetivo.Meta,
+		})
+	}
+	muMissoes.Unlock()
+
+	enviarResposta(conn, Resposta{Tipo: "Missoes", Mensagem: "Missões de " + missoes.Dia, Missoes: lista})
+}
+
+// Função para enviar o progresso de todas as conquistas do jogador
+func enviarConquistas(conn net.Conn, id string) {
+	muMissoes.Lock()
+	lista := make([]ProgressoMissao, 0, len(objetivosConquistas))
+	for _, objetivo := range objetivosConquistas {
+		_, desbloqueada := conquistas[id][objetivo.Nome]
+		lista = append(lista, ProgressoMissao{
+			Nome:      objetivo.Nome,
+			Descricao: objetivo.Descricao,
+			Progresso: min(contadores[id][objetivo.Evento], objetivo.Meta),
+			Meta:      objetivo.Meta,
+			Concluida: desbloqueada,
+		})
+	}
+	muMissoes.Unlock()
+
+	enviarResposta(conn, Resposta{Tipo: "Conquistas", Missoes: lista})
+}
+
+// Função para listar os nomes das conquistas desbloqueadas, mostradas no perfil
+func conquistasJogador(id string) []string {
+	muMissoes.Lock()
+	defer muMissoes.Unlock()
+
+	nomes := make([]string, 0)
+	for _, objetivo := range objetivosConquistas {
+		if _, desbloqueada := conquistas[id][objetivo.Nome]; desbloqueada {
+			nomes = append(nomes, objetivo.Nome)
+		}
+	}
+	return nomes
+}
+
+// Função para registrar a batalha encerrada nas missões de cada participante
+func registrarBatalhaMissoes(batalha *Batalha) {
+	for _, p := range batalha.Participantes {
+		registrarEventoMissao(p.Id, "Batalha", 1)
+		if batalha.venceu(p.Id) {
+			registrarEventoMissao(p.Id, "Vitoria", 1)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/fatih/color"
+)
+
+// Códigos dos erros de moderação enviados ao jogador
+const (
+	CodigoBloqueado       = "Bloqueado"        //Destinatário bloqueou o remetente
+	CodigoSilenciado      = "Silenciado"       //Remetente foi silenciado por um administrador
+	CodigoPalavraProibida = "Palavra_Proibida" //Mensagem com palavra do filtro
+	CodigoLimiteMensagens = "Limite_Mensagens" //Remetente passou do limite de mensagens
+	CodigoSemPermissao    = "Sem_Permissao"    //Comando exclusivo de administradores
+)
+
+// Constantes da moderação
+const (
+	MaxMensagensJanela = 5                //Quantidade máxima de mensagens por jogador dentro da janela
+	JanelaMensagens    = 10 * time.Second //Janela do limite de mensagens
+	MaxBloqueios       = 100              //Quantidade máxima de jogadores bloqueados por jogador
+)
+
+// Palavras proibidas usadas quando o servidor não recebe um arquivo de filtro
+var palavrasPadrao = []string{"idiota", "otario", "otário", "imbecil", "babaca"}
+
+// Erro de moderação com o código específico da violação
+type ErroModeracao struct {
+	Codigo   string
+	Mensagem string
+}
+
+func (e *ErroModeracao) Error() string {
+	return e.Mensagem
+}
+
+// Variáveis da moderação
+var (
+	bloqueios         = make(map[string]map[string]bool) //Jogadores bloqueados por cada jogador
+	silenciados       = make(map[string]time.Time)       //Fim do silêncio de cada jogador silenciado, pelo id da sessão
+	envios            = make(map[string][]time.Time)     //Horários das últimas mensagens de cada jogador
+	admins            = make(map[string]bool)            //Jogadores autenticados como administradores
+	palavrasProibidas = make(map[string]bool)            //Filtro de palavras, em minúsculas
+	senhaAdmin        string                             //Senha dos administradores, vazia desativa o comando
+	muModeracao       sync.Mutex                         //Mutex para sincronizar a moderação
+)
+
+// Função para carregar o filtro de palavras do arquivo, uma palavra por linha.
+// Sem arquivo, ou se ele não puder ser lido, o filtro usa as palavras padrão
+func carregarFiltro(arquivo string) {
+	palavras := palavrasPadrao
+	if arquivo != "" {
+		if lidas, err := lerFiltro(arquivo); err != nil {
+			color.Red("Erro ao ler o filtro de palavras %s, usando as palavras padrão: %v", arquivo, err)
+		} else {
+			palavras = lidas
+		}
+	}
+
+	muModeracao.Lock()
+	for _, palavra := range palavras {
+		palavrasProibidas[strings.ToLower(palavra)] = true
+	}
+	muModeracao.Unlock()
+	color.Green("Filtro de chat com %d palavras", len(palavras))
+}
+
+// Função para ler as palavras do arquivo de filtro, ignorando linhas vazias e comentários com "#"
+func lerFiltro(arquivo string) ([]string, error) {
+	f, err := os.Open(arquivo)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var palavras []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if linha := strings.TrimSpace(scanner.Text()); linha != "" && !strings.HasPrefix(linha, "#") {
+			palavras = append(palavras, linha)
+		}
+	}
+	return palavras, scanner.Err()
+}
+
+// Função para verificar se o jogador pode enviar a mensagem: silêncio, limite de mensagens e filtro de palavras
+func verificarMensagem(id, texto string) error {
+	muModeracao.Lock()
+	defer muModeracao.Unlock()
+	agora := time.Now()
+
+	if fim, existe := silenciados[id]; existe {
+		if agora.Before(fim) {
+			return &ErroModeracao{CodigoSilenciado, fmt.Sprintf("Você está silenciado por mais %s", fim.Sub(agora).Round(time.Second))}
+		}
+		delete(silenciados, id)
+	}
+
+	//Só os envios dentro da janela contam para o limite
+	recentes := envios[id][:0]
+	for _, horario := range envios[id] {
+		if agora.Sub(horario) < JanelaMensagens {
+			recentes = append(recentes, horario)
+		}
+	}
+	envios[id] = recentes
+	if len(recentes) >= MaxMensagensJanela {
+		espera := JanelaMensagens - agora.Sub(recentes[0])
+		return &ErroModeracao{CodigoLimiteMensagens, fmt.Sprintf("Limite de %d mensagens a cada %s, aguarde %s", MaxMensagensJanela, JanelaMensagens, espera.Round(time.Second))}
+	}
+
+	//Palavras comparadas inteiras, sem diferenciar maiúsculas
+	separador := func(c rune) bool { return !unicode.IsLetter(c) && !unicode.IsDigit(c) }
+	for _, palavra := range strings.FieldsFunc(strings.ToLower(texto), separador) {
+		if palavrasProibidas[palavra] {
+			return &ErroModeracao{CodigoPalavraProibida, "Mensagem bloqueada pelo filtro de palavras"}
+		}
+	}
+
+	envios[id] = append(envios[id], agora)
+	return nil
+}
+
+// Função para verificar se o jogador bloqueou o outro
+func bloqueou(id, outro string) bool {
+	muModeracao.Lock()
+	defer muModeracao.Unlock()
+	return bloqueios[id][outro]
+}
+
+// Função para verificar se o destinatário aceita mensagens e convites do remetente
+func verificarBloqueio(remetente, destinatario string) error {
+	if bloqueou(destinatario, remetente) {
+		return &ErroModeracao{CodigoBloqueado, fmt.Sprintf("Jogador %s bloqueou você", destinatario)}
+	}
+	return nil
+}
+
+// Função para retirar da lista os jogadores que bloquearam o remetente
+func filtrarBloqueados(remetente string, ids []string) []string {
+	muModeracao.Lock()
+	defer muModeracao.Unlock()
+	filtrados := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !bloqueios[id][remetente] {
+			filtrados = append(filtrados, id)
+		}
+	}
+	return filtrados
+}
+
+// Função para enviar o erro ao jogador, com o código quando for um erro de moderação
+func enviarErroModeracao(conn net.Conn, err error) {
+	resposta := Resposta{Tipo: "Erro", Mensagem: err.Error()}
+	var erroModeracao *ErroModeracao
+	if errors.As(err, &erroModeracao) {
+		resposta.Codigo = erroModeracao.Codigo
+	}
+	enviarResposta(conn, resposta)
+}
+
+// Função para bloquear ou desbloquear um jogador pelo id ou apelido
+func alterarBloqueio(conn net.Conn, id, alvo string, bloquear bool) {
+	alvoId, online := buscarJogadorOnline(alvo)
+	muModeracao.Lock()
+	jaBloqueado := bloqueios[id][alvoId]
+	muModeracao.Unlock()
+
+	//Desbloquear não exige o jogador online
+	if bloquear && !online {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: fmt.Sprintf("Jogador %s não existe ou não está online", alvo)})
+		return
+	}
+	if alvoId == id {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: "Não é possível bloquear a si mesmo"})
+		return
+	}
+	if !bloquear && !jaBloqueado {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: fmt.Sprintf("Jogador %s não está bloqueado", alvo)})
+		return
+	}
+
+	muModeracao.Lock()
+	if bloquear {
+		if bloqueios[id] == nil {
+			bloqueios[id] = make(map[string]bool)
+		}
+		if len(bloqueios[id]) >= MaxBloqueios && !jaBloqueado {
+			muModeracao.Unlock()
+			enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: fmt.Sprintf("Você já bloqueou %d jogadores", MaxBloqueios)})
+			return
+		}
+		bloqueios[id][alvoId] = true
+	} else {
+		delete(bloqueios[id], alvoId)
+	}
+	muModeracao.Unlock()
+
+	//Bloquear também desfaz a amizade
+	if bloquear {
+		desfazerAmizade(id, alvoId)
+	}
+
+	acao := "desbloqueado"
+	if bloquear {
+		acao = "bloqueado"
+	}
+	enviarResposta(conn, Resposta{Tipo: "Moderacao", Mensagem: fmt.Sprintf("Jogador %s %s", alvoId, acao)})
+
+	//Log do servidor
+	color.Magenta("Jogador %s %s por %s", alvoId, acao, id)
+}
+
+// Função para enviar a lista de jogadores bloqueados
+func listarBloqueados(conn net.Conn, id string) {
+	muModeracao.Lock()
+	lista := make([]string, 0, len(bloqueios[id]))
+	for bloqueado := range bloqueios[id] {
+		lista = append(lista, bloqueado)
+	}
+	muModeracao.Unlock()
+
+	sort.Strings(lista)
+	mensagem := "Nenhum jogador bloqueado"
+	if len(lista) > 0 {
+		mensagem = "Jogadores bloqueados: " + strings.Join(lista, ", ")
+	}
+	enviarResposta(conn, Resposta{Tipo: "Moderacao", Mensagem: mensagem})
+}
+
+// Função para autenticar o jogador como administrador com a senha do servidor
+func autenticarAdmin(conn net.Conn, id, senha string) {
+	if senhaAdmin == "" || strings.TrimSpace(senha) != senhaAdmin {
+		enviarErroModeracao(conn, &ErroModeracao{CodigoSemPermissao, "Senha de administrador inválida"})
+		return
+	}
+
+	muModeracao.Lock()
+	admins[id] = true
+	muModeracao.Unlock()
+
+	enviarResposta(conn, Resposta{Tipo: "Moderacao", Mensagem: "Você agora é administrador"})
+
+	//Log do servidor
+	color.Magenta("Jogador %s autenticado como administrador", id)
+}
+
+// Função para um administrador silenciar um jogador, a partir da mensagem "<id|apelido> <duração>".
+// Duração 0 retira o silêncio
+func silenciarJogador(conn net.Conn, id, mensagem string) {
+	muModeracao.Lock()
+	admin := admins[id]
+	muModeracao.Unlock()
+	if !admin {
+		enviarErroModeracao(conn, &ErroModeracao{CodigoSemPermissao, "Apenas administradores podem silenciar jogadores"})
+		return
+	}
+
+	campos := strings.Fields(mensagem)
+	if len(campos) != 2 {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: "Use Silenciar <id|apelido> <duração>, ex: Silenciar 3 10m"})
+		return
+	}
+	duracao, err := time.ParseDuration(campos[1])
+	if err != nil || duracao < 0 {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: fmt.Sprintf("Duração %s inválida, ex: 30s, 10m, 1h", campos[1])})
+		return
+	}
+	alvo, online := buscarJogadorOnline(campos[0])
+	if !online {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: fmt.Sprintf("Jogador %s não existe ou não está online", campos[0])})
+		return
+	}
+
+	muModeracao.Lock()
+	if duracao == 0 {
+		delete(silenciados, alvo)
+	} else {
+		silenciados[alvo] = time.Now().Add(duracao)
+	}
+	muModeracao.Unlock()
+
+	aviso := fmt.Sprintf("Você foi silenciado por %s", duracao)
+	confirmacao := fmt.Sprintf("Jogador %s silenciado por %s", alvo, duracao)
+	if duracao == 0 {
+		aviso = "Seu silêncio foi retirado"
+		confirmacao = fmt.Sprintf("Silêncio do jogador %s retirado", alvo)
+	}
+	enviarParaJogadores(Resposta{Tipo: "Moderacao", Mensagem: aviso}, alvo)
+	enviarResposta(conn, Resposta{Tipo: "Moderacao", Mensagem: confirmacao})
+
+	//Log do servidor
+	color.Magenta("Administrador %s: %s", id, confirmacao)
+}
+
+// Função para apagar os dados de moderação do jogador desconectado.
+// O silêncio continua até o fim, só os silêncios já vencidos de qualquer jogador são apagados
+func limparModeracao(id string) {
+	muModeracao.Lock()
+	defer muModeracao.Unlock()
+	delete(bloqueios, id)
+	delete(envios, id)
+	delete(admins, id)
+
+	agora := time.Now()
+	for silenciado, fim := range silenciados {
+		if !agora.Before(fim) {
+			delete(silenciados, silenciado)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Função para gerar horários de envio a partir de quanto tempo atrás cada mensagem foi enviada
+func enviosHa(atrasos ...time.Duration) []time.Time {
+	agora := time.Now()
+	horarios := make([]time.Time, 0, len(atrasos))
+	for _, atraso := range atrasos {
+		horarios = append(horarios, agora.Add(-atraso))
+	}
+	return horarios
+}
+
+// Função para repetir o mesmo atraso n vezes
+func repetir(atraso time.Duration, n int) []time.Duration {
+	atrasos := make([]time.Duration, n)
+	for i := range atrasos {
+		atrasos[i] = atraso
+	}
+	return atrasos
+}
+
+func TestVerificarMensagem(t *testing.T) {
+	recente := JanelaMensagens / 10
+	antigo := JanelaMensagens + time.Second
+
+	casos := []struct {
+		nome         string
+		envios       []time.Duration //Há quanto tempo cada mensagem anterior foi enviada
+		silencio     time.Duration   //Tempo restante do silêncio, negativo para silêncio vencido, 0 sem silêncio
+		texto        string
+		codigo       string //Código esperado, vazio se a mensagem pode ser enviada
+		enviosDepois int
+		silencioFica bool
+	}{
+		{nome: "primeira mensagem", texto: "oi", enviosDepois: 1},
+		{nome: "última mensagem dentro do limite", envios: repetir(recente, MaxMensagensJanela-1), texto: "oi", enviosDepois: MaxMensagensJanela},
+		{nome: "limite atingido na janela", envios: repetir(recente, MaxMensagensJanela), texto: "oi", codigo: CodigoLimiteMensagens, enviosDepois: MaxMensagensJanela},
+		{nome: "envios fora da janela não contam", envios: repetir(antigo, MaxMensagensJanela), texto: "oi", enviosDepois: 1},
+		{nome: "só os envios recentes contam", envios: append(repetir(antigo, 3), repetir(recente, MaxMensagensJanela)...), texto: "oi", codigo: CodigoLimiteMensagens, enviosDepois: MaxMensagensJanela},
+		{nome: "jogador silenciado", silencio: time.Minute, texto: "oi", codigo: CodigoSilenciado, silencioFica: true},
+		{nome: "silêncio vencido é retirado", silencio: -time.Second, texto: "oi", enviosDepois: 1},
+		{nome: "palavra proibida não conta no limite", envios: repetir(recente, 2), texto: "seu IDIOTA!", codigo: CodigoPalavraProibida, enviosDepois: 2},
+		{nome: "palavra proibida só inteira", texto: "idiotamente", enviosDepois: 1},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			palavrasProibidas = map[string]bool{"idiota": true}
+			envios = map[string][]time.Time{"1": enviosHa(c.envios...)}
+			silenciados = make(map[string]time.Time)
+			if c.silencio != 0 {
+				silenciados["1"] = time.Now().Add(c.silencio)
+			}
+
+			err := verificarMensagem("1", c.texto)
+
+			codigo := ""
+			var erroModeracao *ErroModeracao
+			if errors.As(err, &erroModeracao) {
+				codigo = erroModeracao.Codigo
+			} else if err != nil {
+				t.Fatalf("erro sem código de moderação: %v", err)
+			}
+			if codigo != c.codigo {
+				t.Errorf("código %q, esperado %q (%v)", codigo, c.codigo, err)
+			}
+			if len(envios["1"]) != c.enviosDepois {
+				t.Errorf("%d envios registrados, esperado %d", len(envios["1"]), c.enviosDepois)
+			}
+			if _, existe := silenciados["1"]; existe != c.silencioFica {
+				t.Errorf("silêncio registrado %v, esperado %v", existe, c.silencioFica)
+			}
+		})
+	}
+}
+
+func TestLimiteMensagensSeguidas(t *testing.T) {
+	palavrasProibidas = make(map[string]bool)
+	envios = make(map[string][]time.Time)
+	silenciados = make(map[string]time.Time)
+
+	//Cada jogador tem o próprio limite
+	for _, id := range []string{"1", "2"} {
+		for i := 0; i < MaxMensagensJanela; i++ {
+			if err := verificarMensagem(id, "oi"); err != nil {
+				t.Fatalf("mensagem %d do jogador %s recusada: %v", i+1, id, err)
+			}
+		}
+		var erroModeracao *ErroModeracao
+		if err := verificarMensagem(id, "oi"); !errors.As(err, &erroModeracao) || erroModeracao.Codigo != CodigoLimiteMensagens {
+			t.Errorf("mensagem acima do limite do jogador %s com erro %v", id, err)
+		}
+	}
+}
+
+func TestLimparModeracaoMantemSilencio(t *testing.T) {
+	silenciados = map[string]time.Time{
+		"1": time.Now().Add(time.Minute),
+		"2": time.Now().Add(-time.Second),
+	}
+	envios = map[string][]time.Time{"1": enviosHa(time.Second)}
+
+	limparModeracao("1")
+
+	if _, existe := silenciados["1"]; !existe {
+		t.Error("silêncio ativo apagado na desconexão")
+	}
+	if _, existe := silenciados["2"]; existe {
+		t.Error("silêncio vencido não foi apagado")
+	}
+	if _, existe := envios["1"]; existe {
+		t.Error("envios do jogador desconectado não foram apagados")
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Constantes dos modos draft e selado
+const (
+	PacoteTemporario = "Basico" //Pacote usado para gerar as cartas temporárias
+	SobraDraft       = 4        //Cartas a mais no pool do draft além das escolhidas pelos dois jogadores
+)
+
+// Função para sortear as cartas de pacotes até juntar a quantidade pedida, sem cunhar.
+// As cartas são temporárias e existem só na partida
+func sortearTemporarias(quantidade int, id string, r *rand.Rand) []Tanque {
+	tipo, _ := buscarTipoPacote(PacoteTemporario)
+	cartas := make([]Tanque, 0, quantidade)
+	for len(cartas) < quantidade {
+		for _, i := range r.Perm(len(tipo.Cartas))[:tipo.Quantidade] {
+			m := tipo.Cartas[i]
+			cartas = append(cartas, Tanque{Modelo: m.Modelo, Id_jogador: id, Vida: m.Vida, Ataque: m.Ataque})
+		}
+	}
+	return cartas[:quantidade]
+}
+
+// Função para validar o pedido de batalha draft ou selada contra o parceiro e buscar as regras
+func validarModoTemporario(conn net.Conn, id, idOponente, nomeRegras string) (Regras, bool) {
+	var resposta Resposta
+
+	if nomeRegras == "None" {
+		nomeRegras = ""
+	}
+	regras, ok := buscarRegras(strings.TrimSpace(nomeRegras))
+	if !ok {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Regras de batalha %s não existem", nomeRegras)
+		enviarResposta(conn, resposta)
+		return Regras{}, false
+	}
+	if err := validarOponente(id, idOponente); err != nil {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return Regras{}, false
+	}
+	return regras, true
+}
+
+// Função para iniciar uma batalha selada: cada jogador abre pacotes temporários e batalha com eles
+func iniciarSelado(conn net.Conn, id, idOponente, nomeRegras string) {
+	regras, ok := validarModoTemporario(conn, id, idOponente, nomeRegras)
+	if !ok {
+		return
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	decks := map[string][]Tanque{
+		id:         sortearTemporarias(regras.TamanhoDeck, id, r),
+		idOponente: sortearTemporarias(regras.TamanhoDeck, idOponente, r),
+	}
+	batalha, err := novaBatalha(ModoDuelo, []string{id, idOponente}, regras, decks, nil)
+	if err != nil {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: err.Error()})
+		return
+	}
+	for _, p := range batalha.Participantes {
+		resposta := Resposta{Tipo: "Selado", Mensagem: fmt.Sprintf("Batalha selada! Você abriu pacotes %s temporários, as cartas existem só nesta partida:", PacoteTemporario), Cartas: p.Deck}
+		enviarParaJogadores(resposta, p.Id)
+	}
+
+	color.Yellow("Batalha selada entre %s e %s", id, idOponente)
+	go realizarBatalha(batalha)
+}
+
+// Função para iniciar uma batalha draft: os jogadores escolhem alternadamente de um pool compartilhado
+func iniciarDraft(conn net.Conn, id, idOponente, nomeRegras string) {
+	regras, ok := validarModoTemporario(conn, id, idOponente, nomeRegras)
+	if !ok {
+		return
+	}
+
+	//Os decks são montados pelas escolhas do draft
+	decks := map[string][]Tanque{id: {}, idOponente: {}}
+	batalha, err := novaBatalha(ModoDuelo, []string{id, idOponente}, regras, decks, nil)
+	if err != nil {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: err.Error()})
+		return
+	}
+	go realizarDraft(batalha)
+}
+
+// Função para realizar as escolhas do draft e depois a batalha com os decks montados.
+// Cada escolha usa o mesmo pedido de carta da batalha, quem não escolhe a tempo leva a primeira do pool
+func realizarDraft(batalha *Batalha) {
+	r := rand.New(rand.NewSource(batalha.Semente))
+	tamanho := batalha.Regras.TamanhoDeck
+	pool := sortearTemporarias(2*tamanho+SobraDraft, "", r)
+	ids := batalha.ids()
+
+	resposta := Resposta{Tipo: "Draft", Mensagem: fmt.Sprintf("Draft iniciado! Escolham %d cartas cada, alternadamente, do pool:", tamanho), Cartas: pool}
+	enviarParaJogadores(resposta, ids...)
+	color.Yellow("Draft entre %s com pool de %d cartas", strings.Join(ids, ", "), len(pool))
+
+	for _, p := range batalha.Participantes {
+		p.Deck = make([]Tanque, 0, tamanho)
+	}
+	for escolha := 0; escolha < 2*tamanho; escolha++ {
+		p := batalha.Participantes[escolha%2]
+
+		//Jogador que desconectou não é esperado
+		var carta *Tanque
+		ok := false
+		if !batalha.desconectou(p.Id) {
+			carta, ok = esperarCarta(p.Id, p.Canal, &pool, batalha.Regras.TempoTurno)
+		}
+		if !ok {
+			primeira := pool[0]
+			carta = &primeira
+			pool = pool[1:]
+		}
+		carta.Id_jogador = p.Id
+		p.Deck = append(p.Deck, *carta)
+
+		resposta := Resposta{Tipo: "Draft", Mensagem: fmt.Sprintf("Jogador %s escolheu %s (Vida %d / Ataque %d)", p.Id, carta.Modelo, carta.Vida, carta.Ataque)}
+		if !ok {
+			resposta.Mensagem = fmt.Sprintf("Jogador %s não escolheu a tempo e levou %s (Vida %d / Ataque %d)", p.Id, carta.Modelo, carta.Vida, carta.Ataque)
+		}
+		enviarParaJogadores(resposta, ids...)
+		time.Sleep(batalha.Regras.Ritmo)
+	}
+
+	for _, p := range batalha.Participantes {
+		p.DeckInicial = append([]Tanque(nil), p.Deck...)
+	}
+	realizarBatalha(batalha)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Tipo de pacote vendido pelo servidor
+type TipoPacote struct {
+	Nome       string
+	Preco      int
+	Quantidade int           //Cartas sorteadas por pacote
+	Cartas     []ModeloCarta //Cartas que podem ser sorteadas
+}
+
+// Variáveis das moedas
+var (
+	saldos      = make(map[string]int)    //Moedas de cada jogador
+	bonusDiario = make(map[string]string) //Último dia em que o jogador recebeu o bônus de login
+	muSaldos    sync.Mutex                //Mutex para sincronizar os saldos
+)
+
+// Constantes das recompensas
+const (
+	RecompensaLogin   = 200 //Bônus do primeiro login do dia
+	RecompensaVitoria = 50  //Moedas por batalha vencida
+	PacotePadrao      = "Basico"
+)
+
+// Pacotes à venda, do mais barato ao mais caro
+var tiposPacote = []TipoPacote{
+	{"Basico", 100, 5, pacote_1},
+	{"Reforcado", 250, 5, filtrarCartas(pacote_1, "(Medium)", "(Heavy)")},
+	{"Pesado", 400, 3, filtrarCartas(pacote_1, "(Heavy)")},
+}
+
+// Função para separar as cartas do pacote cujo modelo tem alguma das classes informadas
+func filtrarCartas(pacote []ModeloCarta, classes ...string) []ModeloCarta {
+	cartas := make([]ModeloCarta, 0)
+	for _, carta := range pacote {
+		for _, classe := range classes {
+			if strings.HasSuffix(carta.Modelo, classe) {
+				cartas = append(cartas, carta)
+				break
+			}
+		}
+	}
+	return cartas
+}
+
+// Função para buscar o tipo de pacote pelo nome, sem diferenciar maiúsculas
+func buscarTipoPacote(nome string) (TipoPacote, bool) {
+	nome = strings.TrimSpace(nome)
+	if nome == "" || nome == "None" {
+		nome = PacotePadrao
+	}
+	for _, tipo := range tiposPacote {
+		if strings.EqualFold(tipo.Nome, nome) {
+			return tipo, true
+		}
+	}
+	return TipoPacote{}, false
+}
+
+// Função para adicionar moedas ao jogador, retorna o saldo novo
+func creditarMoedas(id string, valor int) int {
+	muSaldos.Lock()
+	defer muSaldos.Unlock()
+	saldos[id] += valor
+	return saldos[id]
+}
+
+// Função para consultar o saldo do jogador
+func saldoJogador(id string) int {
+	muSaldos.Lock()
+	defer muSaldos.Unlock()
+	return saldos[id]
+}
+
+// Função para dar o bônus de login uma vez por dia ao jogador.
+// Quem reconecta com o token da sessão mantém o id, então não recebe o bônus de novo no mesmo dia
+func concederBonusDiario(conn net.Conn, id string) {
+	hoje := time.Now().Format("2006-01-02")
+
+	muSaldos.Lock()
+	if bonusDiario[id] == hoje {
+		muSaldos.Unlock()
+		return
+	}
+	bonusDiario[id] = hoje
+	saldos[id] += RecompensaLogin
+	saldo := saldos[id]
+	muSaldos.Unlock()
+
+	enviarResposta(conn, Resposta{Tipo: "Saldo", Mensagem: fmt.Sprintf("Bônus diário de %d moedas! Saldo: %d moedas", RecompensaLogin, saldo)})
+}
+
+// Função para pagar os vencedores da batalha e avisá-los do saldo novo
+func premiarVencedores(batalha *Batalha) {
+	if batalha.ContraIA {
+		return
+	}
+	for _, id := range batalha.Vencedores {
+		saldo := creditarMoedas(id, RecompensaVitoria)
+		resposta := Resposta{Tipo: "Saldo", Mensagem: fmt.Sprintf("Você ganhou %d moedas pela vitória! Saldo: %d moedas", RecompensaVitoria, saldo)}
+		enviarParaJogadores(resposta, id)
+
+		//Log do servidor
+		color.Green("Jogador %s ganhou %d moedas, saldo %d", id, RecompensaVitoria, saldo)
+	}
+}
+
+// Função para enviar o saldo de moedas e sucata do jogador e os preços dos pacotes
+func enviarSaldo(conn net.Conn, id string) {
+	precos := make([]string, 0, len(tiposPacote))
+	for _, tipo := range tiposPacote {
+		precos = append(precos, fmt.Sprintf("%s %d moedas (%d cartas)", tipo.Nome, tipo.Preco, tipo.Quantidade))
+	}
+	mensagem := fmt.Sprintf("Saldo: %d moedas e %d de sucata. Pacotes: %s", saldoJogador(id), sucataJogador(id), strings.Join(precos, ", "))
+	enviarResposta(conn, Resposta{Tipo: "Saldo", Mensagem: mensagem})
+}
+
+// Função para sortear as cartas de um pacote com um gerador independente
+func sortearDoPacote(tipo TipoPacote, id string) []Tanque {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	indices := r.Perm(len(tipo.Cartas))[:tipo.Quantidade]
+
+	cartasSorteadas := make([]Tanque, 0, tipo.Quantidade)
+	for _, i := range indices {
+		cartasSorteadas = append(cartasSorteadas, cunharCarta(tipo.Cartas[i], id, "Pacote "+tipo.Nome))
+	}
+	return cartasSorteadas
+}
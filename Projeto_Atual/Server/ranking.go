@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// Rating Elo e resultados de um jogador
+type Rating struct {
+	Pontos   float64
+	Vitorias int
+	Derrotas int
+	Empates  int
+}
+
+// Linha da tabela de ranking enviada ao cliente
+type PosicaoRanking struct {
+	Posicao  int    `json:"posicao"`
+	Id       string `json:"id"`
+	Rating   int    `json:"rating"`
+	Vitorias int    `json:"vitorias"`
+	Derrotas int    `json:"derrotas"`
+	Empates  int    `json:"empates"`
+}
+
+// Variáveis do ranking
+var (
+	ratings   = make(map[string]*Rating) //Rating de cada jogador que já terminou uma partida ranqueada
+	muRatings sync.Mutex                 //Mutex para sincronizar os ratings
+)
+
+// Constantes do cálculo Elo
+const (
+	RatingInicial  = 1200.0
+	FatorK         = 32.0
+	TamanhoRanking = 10 //Quantidade padrão de jogadores na tabela
+)
+
+// Regras de pontuação:
+//   - Vitória ou derrota normal, inclusive por timeout na escolha de carta, conta como resultado comum.
+//   - Quem desconecta é eliminado da batalha, contando derrota normal se a batalha tiver vencedor.
+//   - Empate (dano total igual ou série empatada) conta 0.5 para cada lado.
+//   - Batalha encerrada à força sem vencedor conta derrota apenas para quem desconectou,
+//     os outros participantes não ganham nem perdem pontos.
+//   - Batalhas de uma série não contam individualmente, apenas o resultado da série.
+//   - Batalhas contra o bot do servidor não contam no ranking.
+
+// Função para registrar o resultado de uma batalha avulsa no ranking
+func registrarResultadoBatalha(batalha *Batalha, desconectados []string) {
+	if batalha.EmSerie || batalha.ContraIA {
+		return
+	}
+
+	//Batalha sem vencedor por desconexão: derrota só para quem desconectou
+	if len(batalha.Vencedores) == 0 && len(desconectados) > 0 {
+		for _, id := range desconectados {
+			aplicarPenalidade(id, batalha.ids())
+		}
+		return
+	}
+
+	//Empate: cada equipe empata com as outras, com peso dividido entre elas
+	if len(batalha.Vencedores) == 0 {
+		equipes := make([][]string, 0)
+		indices := make(map[int]int)
+		for _, p := range batalha.Participantes {
+			if _, existe := indices[p.Equipe]; !existe {
+				indices[p.Equipe] = len(equipes)
+				equipes = append(equipes, nil)
+			}
+			equipes[indices[p.Equipe]] = append(equipes[indices[p.Equipe]], p.Id)
+		}
+		for i := 0; i < len(equipes); i++ {
+			for j := i + 1; j < len(equipes); j++ {
+				atualizarElo(equipes[i], equipes[j], 0.5, FatorK/float64(len(equipes)-1))
+			}
+		}
+		return
+	}
+
+	perdedores := make([]string, 0)
+	for _, p := range batalha.Participantes {
+		if !batalha.venceu(p.Id) {
+			perdedores = append(perdedores, p.Id)
+		}
+	}
+
+	//Todos contra todos: vencedor contra cada perdedor, com peso dividido
+	if batalha.Modo == ModoTodosContraTodos {
+		for _, perdedor := range perdedores {
+			atualizarElo(batalha.Vencedores, []string{perdedor}, 1, FatorK/float64(len(perdedores)))
+		}
+		return
+	}
+
+	atualizarElo(batalha.Vencedores, perdedores, 1, FatorK)
+}
+
+// Função para registrar o resultado de uma série inteira como uma única partida
+func registrarResultadoSerie(serie *Serie, vencedor string) {
+	switch vencedor {
+	case serie.Jogador1:
+		atualizarElo([]string{serie.Jogador1}, []string{serie.Jogador2}, 1, FatorK)
+	case serie.Jogador2:
+		atualizarElo([]string{serie.Jogador2}, []string{serie.Jogador1}, 1, FatorK)
+	default:
+		//Série interrompida: quem desconectou perde, se os 2 ainda estão conectados é empate
+		muClientes.RLock()
+		_, conectado1 := clientes[serie.Jogador1]
+		_, conectado2 := clientes[serie.Jogador2]
+		muClientes.RUnlock()
+
+		if !conectado1 {
+			aplicarPenalidade(serie.Jogador1, []string{serie.Jogador2})
+		}
+		if !conectado2 {
+			aplicarPenalidade(serie.Jogador2, []string{serie.Jogador1})
+		}
+		if conectado1 && conectado2 {
+			atualizarElo([]string{serie.Jogador1}, []string{serie.Jogador2}, 0.5, FatorK)
+		}
+	}
+}
+
+// Função para aplicar uma derrota ao jogador sem alterar o rating dos oponentes
+func aplicarPenalidade(id string, participantes []string) {
+	oponentes := make([]string, 0, len(participantes))
+	for _, p := range participantes {
+		if p != id {
+			oponentes = append(oponentes, p)
+		}
+	}
+	if len(oponentes) == 0 {
+		return
+	}
+
+	muRatings.Lock()
+	defer muRatings.Unlock()
+
+	r := buscarRating(id)
+	esperado := probabilidadeVitoria(r.Pontos, mediaRating(oponentes))
+	r.Pontos -= FatorK * esperado
+	r.Derrotas++
+
+	//Log do servidor
+	color.Yellow("Jogador %s perdeu rating por desconexão (%.0f)", id, r.Pontos)
+}
+
+// Função para atualizar o rating Elo de 2 lados usando a média de cada equipe.
+// resultado é 1 para vitória do lado A, 0.5 para empate
+func atualizarElo(ladoA, ladoB []string, resultado, k float64) {
+	muRatings.Lock()
+	defer muRatings.Unlock()
+
+	mediaA := mediaRating(ladoA)
+	mediaB := mediaRating(ladoB)
+	esperadoA := probabilidadeVitoria(mediaA, mediaB)
+	variacao := k * (resultado - esperadoA)
+
+	for _, id := range ladoA {
+		r := buscarRating(id)
+		r.Pontos += variacao
+		contarResultado(r, resultado)
+	}
+	for _, id := range ladoB {
+		r := buscarRating(id)
+		r.Pontos -= variacao
+		contarResultado(r, 1-resultado)
+	}
+}
+
+// Função para contar vitória, derrota ou empate conforme o resultado do jogador
+func contarResultado(r *Rating, resultado float64) {
+	switch resultado {
+	case 1:
+		r.Vitorias++
+	case 0:
+		r.Derrotas++
+	default:
+		r.Empates++
+	}
+}
+
+// Função para pegar o rating do jogador, criando com o valor inicial se não existir.
+// Deve ser chamada com muRatings bloqueado
+func buscarRating(id string) *Rating {
+	r, existe := ratings[id]
+	if !existe {
+		r = &Rating{Pontos: RatingInicial}
+		ratings[id] = r
+	}
+	return r
+}
+
+// Função para calcular a média de rating de um grupo de jogadores.
+// Deve ser chamada com muRatings bloqueado
+func mediaRating(ids []string) float64 {
+	soma := 0.0
+	for _, id := range ids {
+		soma += buscarRating(id).Pontos
+	}
+	return soma / float64(len(ids))
+}
+
+// Função para calcular a chance esperada de vitória de A contra B
+func probabilidadeVitoria(ratingA, ratingB float64) float64 {
+	return 1 / (1 + math.Pow(10, (ratingB-ratingA)/400))
+}
+
+// Função para montar a tabela completa do ranking ordenada por rating
+func tabelaRanking() []PosicaoRanking {
+	muRatings.Lock()
+	defer muRatings.Unlock()
+	return montarTabelaRanking()
+}
+
+// Função para montar a tabela do ranking. Deve ser chamada com muRatings bloqueado
+func montarTabelaRanking() []PosicaoRanking {
+	tabela := make([]PosicaoRanking, 0, len(ratings))
+	for id, r := range ratings {
+		tabela = append(tabela, PosicaoRanking{
+			Id:       id,
+			Rating:   int(math.Round(r.Pontos)),
+			Vitorias: r.Vitorias,
+			Derrotas: r.Derrotas,
+			Empates:  r.Empates,
+		})
+	}
+
+	sort.Slice(tabela, func(i, j int) bool {
+		if tabela[i].Rating != tabela[j].Rating {
+			return tabela[i].Rating > tabela[j].Rating
+		}
+		return tabela[i].Id < tabela[j].Id
+	})
+	for i := range tabela {
+		tabela[i].Posicao = i + 1
+	}
+	return tabela
+}
+
+// Função para enviar os N melhores jogadores e a posição do próprio jogador
+func enviarRanking(conn net.Conn, id, mensagem string) {
+	quantidade, err := strconv.Atoi(mensagem)
+	if err != nil || quantidade <= 0 {
+		quantidade = TamanhoRanking
+	}
+
+	tabela := tabelaRanking()
+
+	resposta := Resposta{Tipo: "Ranking", Mensagem: "Você ainda não tem partidas ranqueadas"}
+	for _, posicao := range tabela {
+		if posicao.Id == id {
+			resposta.Mensagem = fmt.Sprintf("Sua posição: %dº de %d com rating %d", posicao.Posicao, len(tabela), posicao.Rating)
+		}
+	}
+
+	if len(tabela) > quantidade {
+		tabela = tabela[:quantidade]
+	}
+	resposta.Ranking = tabela
+	enviarResposta(conn, resposta)
+}
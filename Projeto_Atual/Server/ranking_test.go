@@ -0,0 +1,178 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// Função para comparar ratings com tolerância de arredondamento
+func quaseIgual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-3
+}
+
+func TestProbabilidadeVitoria(t *testing.T) {
+	casos := []struct {
+		nome     string
+		a, b     float64
+		esperado float64
+	}{
+		{"ratings iguais", 1200, 1200, 0.5},
+		{"200 pontos acima", 1400, 1200, 0.75975},
+		{"200 pontos abaixo", 1200, 1400, 0.24025},
+		{"400 pontos acima", 1600, 1200, 10.0 / 11.0},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if obtido := probabilidadeVitoria(c.a, c.b); !quaseIgual(obtido, c.esperado) {
+				t.Errorf("probabilidadeVitoria(%v, %v) = %v, esperado %v", c.a, c.b, obtido, c.esperado)
+			}
+		})
+	}
+}
+
+func TestAtualizarElo(t *testing.T) {
+	casos := []struct {
+		nome      string
+		iniciais  map[string]float64
+		ladoA     []string
+		ladoB     []string
+		resultado float64
+		k         float64
+		finais    map[string]float64
+		vitorias  map[string]int
+		derrotas  map[string]int
+		empates   map[string]int
+	}{
+		{
+			nome:      "vitória entre ratings iguais",
+			iniciais:  map[string]float64{"1": 1200, "2": 1200},
+			ladoA:     []string{"1"},
+			ladoB:     []string{"2"},
+			resultado: 1,
+			k:         FatorK,
+			finais:    map[string]float64{"1": 1216, "2": 1184},
+			vitorias:  map[string]int{"1": 1},
+			derrotas:  map[string]int{"2": 1},
+		},
+		{
+			nome:      "favorito vence e ganha pouco",
+			iniciais:  map[string]float64{"1": 1400, "2": 1200},
+			ladoA:     []string{"1"},
+			ladoB:     []string{"2"},
+			resultado: 1,
+			k:         FatorK,
+			finais:    map[string]float64{"1": 1407.688, "2": 1192.312},
+			vitorias:  map[string]int{"1": 1},
+			derrotas:  map[string]int{"2": 1},
+		},
+		{
+			nome:      "azarão vence e ganha muito",
+			iniciais:  map[string]float64{"1": 1200, "2": 1400},
+			ladoA:     []string{"1"},
+			ladoB:     []string{"2"},
+			resultado: 1,
+			k:         FatorK,
+			finais:    map[string]float64{"1": 1224.312, "2": 1375.688},
+			vitorias:  map[string]int{"1": 1},
+			derrotas:  map[string]int{"2": 1},
+		},
+		{
+			nome:      "empate entre ratings iguais não muda",
+			iniciais:  map[string]float64{"1": 1200, "2": 1200},
+			ladoA:     []string{"1"},
+			ladoB:     []string{"2"},
+			resultado: 0.5,
+			k:         FatorK,
+			finais:    map[string]float64{"1": 1200, "2": 1200},
+			empates:   map[string]int{"1": 1, "2": 1},
+		},
+		{
+			nome:      "empate tira pontos do favorito",
+			iniciais:  map[string]float64{"1": 1400, "2": 1200},
+			ladoA:     []string{"1"},
+			ladoB:     []string{"2"},
+			resultado: 0.5,
+			k:         FatorK,
+			finais:    map[string]float64{"1": 1391.688, "2": 1208.312},
+			empates:   map[string]int{"1": 1, "2": 1},
+		},
+		{
+			nome:      "equipes usam a média e todos recebem a mesma variação",
+			iniciais:  map[string]float64{"1": 1300, "2": 1100, "3": 1200, "4": 1200},
+			ladoA:     []string{"1", "2"},
+			ladoB:     []string{"3", "4"},
+			resultado: 1,
+			k:         FatorK,
+			finais:    map[string]float64{"1": 1316, "2": 1116, "3": 1184, "4": 1184},
+			vitorias:  map[string]int{"1": 1, "2": 1},
+			derrotas:  map[string]int{"3": 1, "4": 1},
+		},
+		{
+			nome:      "peso dividido no todos contra todos",
+			iniciais:  map[string]float64{"1": 1200, "2": 1200},
+			ladoA:     []string{"1"},
+			ladoB:     []string{"2"},
+			resultado: 1,
+			k:         FatorK / 2,
+			finais:    map[string]float64{"1": 1208, "2": 1192},
+			vitorias:  map[string]int{"1": 1},
+			derrotas:  map[string]int{"2": 1},
+		},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			ratings = make(map[string]*Rating)
+			for id, pontos := range c.iniciais {
+				ratings[id] = &Rating{Pontos: pontos}
+			}
+
+			atualizarElo(c.ladoA, c.ladoB, c.resultado, c.k)
+
+			for id, esperado := range c.finais {
+				r := ratings[id]
+				if !quaseIgual(r.Pontos, esperado) {
+					t.Errorf("jogador %s com rating %.3f, esperado %.3f", id, r.Pontos, esperado)
+				}
+				if r.Vitorias != c.vitorias[id] || r.Derrotas != c.derrotas[id] || r.Empates != c.empates[id] {
+					t.Errorf("jogador %s com %d/%d/%d, esperado %d/%d/%d", id, r.Vitorias, r.Derrotas, r.Empates, c.vitorias[id], c.derrotas[id], c.empates[id])
+				}
+			}
+		})
+	}
+}
+
+func TestAplicarPenalidade(t *testing.T) {
+	casos := []struct {
+		nome          string
+		iniciais      map[string]float64
+		id            string
+		participantes []string
+		final         float64
+		derrotas      int
+	}{
+		{"contra rating igual", map[string]float64{"1": 1200, "2": 1200}, "1", []string{"1", "2"}, 1184, 1},
+		{"contra a média dos oponentes", map[string]float64{"1": 1200, "2": 1100, "3": 1300}, "1", []string{"1", "2", "3"}, 1184, 1},
+		{"sem oponentes não muda", map[string]float64{"1": 1200}, "1", []string{"1"}, 1200, 0},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			ratings = make(map[string]*Rating)
+			for id, pontos := range c.iniciais {
+				ratings[id] = &Rating{Pontos: pontos}
+			}
+
+			aplicarPenalidade(c.id, c.participantes)
+
+			r := ratings[c.id]
+			if !quaseIgual(r.Pontos, c.final) || r.Derrotas != c.derrotas {
+				t.Errorf("jogador %s com rating %.3f e %d derrotas, esperado %.3f e %d", c.id, r.Pontos, r.Derrotas, c.final, c.derrotas)
+			}
+			//Os oponentes não ganham pontos pela desconexão
+			for id, pontos := range c.iniciais {
+				if id != c.id && !quaseIgual(ratings[id].Pontos, pontos) {
+					t.Errorf("oponente %s mudou para %.3f", id, ratings[id].Pontos)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Constantes das condições de vitória de uma batalha
+const (
+	VitoriaUltimoTanque = iota //Vence quem ainda tiver tanques quando o oponente ficar sem cartas
+	VitoriaDanoTotal           //Vence quem causar mais dano após um número fixo de turnos
+)
+
+// Regras usadas em uma batalha
+type Regras struct {
+	Nome         string
+	TamanhoDeck  int           //Quantidade de cartas no deck de batalha
+	TempoTurno   time.Duration //Tempo máximo para o jogador escolher uma carta
+	Ritmo        time.Duration //Pausa entre os turnos
+	Vitoria      int           //Condição de vitória
+	LimiteTurnos int           //Número de turnos da batalha por dano total
+}
+
+// Nome das regras usadas quando o jogador não escolhe nenhuma
+const RegrasPadrao = "Padrao"
+
+// Conjuntos de regras pré-definidos que podem ser escolhidos ao batalhar
+var presetsRegras = map[string]Regras{
+	"Padrao": {Nome: "Padrao", TamanhoDeck: 5, TempoTurno: 10 * time.Second, Ritmo: 1 * time.Second, Vitoria: VitoriaUltimoTanque},
+	"Rapida": {Nome: "Rapida", TamanhoDeck: 3, TempoTurno: 5 * time.Second, Ritmo: 300 * time.Millisecond, Vitoria: VitoriaUltimoTanque},
+	"Longa":  {Nome: "Longa", TamanhoDeck: 8, TempoTurno: 15 * time.Second, Ritmo: 1 * time.Second, Vitoria: VitoriaUltimoTanque},
+	"Dano":   {Nome: "Dano", TamanhoDeck: 5, TempoTurno: 10 * time.Second, Ritmo: 1 * time.Second, Vitoria: VitoriaDanoTotal, LimiteTurnos: 12},
+}
+
+// Função para buscar um conjunto de regras pelo nome, nome vazio usa as regras padrão
+func buscarRegras(nome string) (Regras, bool) {
+	if nome == "" || nome == "None" {
+		nome = RegrasPadrao
+	}
+	regras, existe := presetsRegras[nome]
+	return regras, existe
+}
+
+// Função para verificar se algum conjunto de regras aceita decks com o tamanho informado
+func tamanhoDeckPermitido(tamanho int) bool {
+	for _, regras := range presetsRegras {
+		if regras.TamanhoDeck == tamanho {
+			return true
+		}
+	}
+	return false
+}
+
+// Função para descrever as regras em uma linha de texto para o jogador
+func (r Regras) descricao() string {
+	vitoria := "último tanque de pé"
+	if r.Vitoria == VitoriaDanoTotal {
+		vitoria = fmt.Sprintf("maior dano total após %d turnos", r.LimiteTurnos)
+	}
+	return fmt.Sprintf("%s: deck de %d cartas, %ds por escolha, %v entre turnos, vitória por %s",
+		r.Nome, r.TamanhoDeck, int(r.TempoTurno.Seconds()), r.Ritmo, vitoria)
+}
+
+// Função para enviar ao jogador a lista de regras disponíveis
+func listarRegras(conn net.Conn) {
+	nomes := make([]string, 0, len(presetsRegras))
+	for nome := range presetsRegras {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+
+	linhas := make([]string, 0, len(nomes))
+	for _, nome := range nomes {
+		linhas = append(linhas, presetsRegras[nome].descricao())
+	}
+
+	resposta := Resposta{Tipo: "Lista_Regras", Mensagem: strings.Join(linhas, "\n")}
+	enviarResposta(conn, resposta)
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Evento público de uma batalha guardado no replay
+type EventoReplay struct {
+	Tempo    int64    `json:"t"` //Milissegundos desde o início da batalha
+	Tipo     string   `json:"tipo"`
+	Mensagem string   `json:"msg"`
+	Cartas   []Tanque `json:"cartas,omitempty"`
+}
+
+// Replay completo de uma batalha, salvo compactado em disco
+type Replay struct {
+	Id        int              `json:"id"` //Mesmo id da partida no histórico
+	Semente   int64            `json:"semente"`
+	Modo      string           `json:"modo"`
+	Regras    Regras           `json:"regras"`
+	Descricao string           `json:"descricao"` //Descrição das regras em texto
+	Jogadores []JogadorPartida `json:"jogadores"`
+	Inicio    time.Time        `json:"inicio"`
+	Eventos   []EventoReplay   `json:"eventos,omitempty"`
+}
+
+// Variáveis dos replays
+var (
+	reproducoes   = make(map[string]bool) //Jogadores recebendo um replay no momento
+	muReproducoes sync.Mutex              //Mutex para sincronizar as reproduções
+)
+
+// Constantes dos replays
+const (
+	DiretorioReplays   = "replays"
+	VelocidadeMinima   = 0.25
+	VelocidadeMaxima   = 16.0
+	IntervaloMinReplay = 20 * time.Millisecond //Pausa mínima entre eventos enviados
+)
+
+// Função para guardar um evento público da batalha no replay.
+// Chamada apenas pela goroutine da batalha
+func (b *Batalha) registrarEvento(resposta Resposta) {
+	b.Eventos = append(b.Eventos, EventoReplay{
+		Tempo:    time.Since(b.Inicio).Milliseconds(),
+		Tipo:     resposta.Tipo,
+		Mensagem: resposta.Mensagem,
+		Cartas:   resposta.Cartas,
+	})
+}
+
+// Função para montar o nome do arquivo de replay da partida
+func arquivoReplay(id int) string {
+	return filepath.Join(DiretorioReplays, fmt.Sprintf("partida_%d.json.gz", id))
+}
+
+// Função para salvar o replay da batalha encerrada em um arquivo json compactado
+func salvarReplay(batalha *Batalha, id int) {
+	replay := Replay{
+		Id:        id,
+		Semente:   batalha.Semente,
+		Modo:      nomeModo(batalha.Modo),
+		Regras:    batalha.Regras,
+		Descricao: batalha.Regras.descricao(),
+		Inicio:    batalha.Inicio,
+		Eventos:   batalha.Eventos,
+	}
+	for _, p := range batalha.Participantes {
+		replay.Jogadores = append(replay.Jogadores, JogadorPartida{Id: p.Id, Equipe: p.Equipe, Deck: p.DeckInicial, Usadas: p.Usadas, Dano: p.Dano})
+	}
+
+	if err := os.MkdirAll(DiretorioReplays, 0755); err != nil {
+		color.Red("Erro ao criar diretório de replays: %v", err)
+		return
+	}
+	arquivo, err := os.Create(arquivoReplay(id))
+	if err != nil {
+		color.Red("Erro ao salvar replay da partida %d: %v", id, err)
+		return
+	}
+	defer arquivo.Close()
+
+	compactador := gzip.NewWriter(arquivo)
+	defer compactador.Close()
+	if err := json.NewEncoder(compactador).Encode(replay); err != nil {
+		color.Red("Erro ao salvar replay da partida %d: %v", id, err)
+	}
+}
+
+// Função para ler do disco o replay de uma partida
+func carregarReplay(id int) (*Replay, error) {
+	arquivo, err := os.Open(arquivoReplay(id))
+	if err != nil {
+		return nil, err
+	}
+	defer arquivo.Close()
+
+	descompactador, err := gzip.NewReader(arquivo)
+	if err != nil {
+		return nil, err
+	}
+	defer descompactador.Close()
+
+	var replay Replay
+	if err := json.NewDecoder(descompactador).Decode(&replay); err != nil {
+		return nil, err
+	}
+	return &replay, nil
+}
+
+// Função para transmitir o replay de uma partida ao jogador.
+// A mensagem tem o id da partida e opcionalmente a velocidade, ex: "4 2" reproduz a partida 4 em velocidade 2x
+func transmitirReplay(conn net.Conn, id, mensagem string) {
+	var resposta Resposta
+
+	campos := strings.Fields(mensagem)
+	if len(campos) == 0 {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Informe o id da partida"
+		enviarResposta(conn, resposta)
+		return
+	}
+	idPartida, err := strconv.Atoi(campos[0])
+	if err != nil {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Id de partida inválido"
+		enviarResposta(conn, resposta)
+		return
+	}
+	velocidade := 1.0
+	if len(campos) > 1 {
+		velocidade, err = strconv.ParseFloat(campos[1], 64)
+		if err != nil || velocidade < VelocidadeMinima || velocidade > VelocidadeMaxima {
+			resposta.Tipo = "Erro"
+			resposta.Mensagem = fmt.Sprintf("Velocidade deve estar entre %.2f e %.0f", VelocidadeMinima, VelocidadeMaxima)
+			enviarResposta(conn, resposta)
+			return
+		}
+	}
+
+	if emBatalha(id) {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Não é possível assistir um replay durante uma batalha"
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	replay, err := carregarReplay(idPartida)
+	if err != nil {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Replay da partida %d não encontrado", idPartida)
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	//Apenas um replay por jogador de cada vez
+	muReproducoes.Lock()
+	if reproducoes[id] {
+		muReproducoes.Unlock()
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Você já está assistindo um replay"
+		enviarResposta(conn, resposta)
+		return
+	}
+	reproducoes[id] = true
+	muReproducoes.Unlock()
+
+	go reproduzirReplay(conn, id, replay, velocidade)
+}
+
+// Função para enviar o cabeçalho e os eventos do replay respeitando o tempo original dividido pela velocidade
+func reproduzirReplay(conn net.Conn, id string, replay *Replay, velocidade float64) {
+	defer func() {
+		muReproducoes.Lock()
+		delete(reproducoes, id)
+		muReproducoes.Unlock()
+	}()
+
+	eventos := replay.Eventos
+	cabecalho := *replay
+	cabecalho.Eventos = nil
+	enviarResposta(conn, Resposta{Tipo: "Replay_Inicio", Mensagem: fmt.Sprintf("%g", velocidade), Replay: &cabecalho})
+
+	//Log do servidor
+	color.Cyan("Jogador %s assistindo replay da partida %d em velocidade %gx", id, replay.Id, velocidade)
+
+	anterior := int64(0)
+	for i := range eventos {
+		espera := time.Duration(float64(eventos[i].Tempo-anterior)/velocidade) * time.Millisecond
+		time.Sleep(max(espera, IntervaloMinReplay))
+		anterior = eventos[i].Tempo
+
+		//Para a transmissão se o jogador desconectou
+		muClientes.RLock()
+		_, conectado := clientes[id]
+		muClientes.RUnlock()
+		if !conectado {
+			return
+		}
+
+		enviarResposta(conn, Resposta{Tipo: "Replay_Evento", Evento: &eventos[i]})
+	}
+
+	enviarResposta(conn, Resposta{Tipo: "Replay_Fim", Mensagem: strconv.Itoa(replay.Id)})
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Série de batalhas melhor de N entre o mesmo par de jogadores
+type Serie struct {
+	Jogador1  string
+	Jogador2  string
+	Jogos     int //Número máximo de batalhas (3 ou 5)
+	Regras    Regras
+	Vitorias1 int
+	Vitorias2 int
+}
+
+// Variáveis das séries em andamento
+var (
+	series   = make(map[string]*Serie) //Map para guardar séries em andamento por jogador
+	muSeries sync.RWMutex              //Mutex para sincronizar as séries
+)
+
+// Pausa entre as batalhas de uma série
+const IntervaloSerie = 3 * time.Second
+
+// Função para iniciar uma série a partir da mensagem "<jogos> [regras]"
+func iniciarSerie(conn net.Conn, id_remetente, id_destinatario, mensagem string) {
+	var resposta Resposta
+
+	campos := strings.Fields(mensagem)
+	jogos := 0
+	if len(campos) > 0 {
+		jogos, _ = strconv.Atoi(campos[0])
+	}
+	if jogos != 3 && jogos != 5 {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "A série deve ser melhor de 3 ou melhor de 5"
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	nomeRegras := ""
+	if len(campos) > 1 {
+		nomeRegras = campos[1]
+	}
+	regras, ok := buscarRegras(nomeRegras)
+	if !ok {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Regras de batalha %s não existem", nomeRegras)
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	if err := validarOponente(id_remetente, id_destinatario); err != nil {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	for _, id := range []string{id_remetente, id_destinatario} {
+		if !deckPronto(id, regras.TamanhoDeck) {
+			resposta.Tipo = "Erro"
+			resposta.Mensagem = fmt.Sprintf("Jogador %s não tem cartas suficientes para um deck com %d", id, regras.TamanhoDeck)
+			enviarResposta(conn, resposta)
+			return
+		}
+	}
+
+	//Conferir e registrar a série no mesmo bloqueio das batalhas
+	serie := &Serie{Jogador1: id_remetente, Jogador2: id_destinatario, Jogos: jogos, Regras: regras}
+	muBatalhas.Lock()
+	err := verificarOcupados([]string{id_remetente, id_destinatario}, nil)
+	if err == nil {
+		muSeries.Lock()
+		series[id_remetente] = serie
+		series[id_destinatario] = serie
+		muSeries.Unlock()
+	}
+	muBatalhas.Unlock()
+	if err != nil {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	go realizarSerie(serie)
+}
+
+// Função para realizar as batalhas da série até um jogador alcançar a maioria das vitórias
+func realizarSerie(serie *Serie) {
+	color.Yellow("Iniciando série melhor de %d entre %s e %s", serie.Jogos, serie.Jogador1, serie.Jogador2)
+	necessarias := serie.Jogos/2 + 1
+
+	for jogo := 0; jogo < serie.Jogos; jogo++ {
+		//Alterna quem ataca primeiro a cada batalha
+		primeiro, segundo := serie.Jogador1, serie.Jogador2
+		if jogo%2 == 1 {
+			primeiro, segundo = segundo, primeiro
+		}
+
+		//As coleções podem mudar entre as batalhas da série
+		batalha, err := novaBatalha(ModoDuelo, []string{primeiro, segundo}, serie.Regras, nil, serie)
+		if err != nil {
+			encerrarSerie(serie, "Ninguém", err.Error())
+			return
+		}
+		realizarBatalha(batalha)
+
+		//Série é interrompida se algum jogador desconectou ou saiu do grupo
+		if !mesmoGrupo(serie.Jogador1, serie.Jogador2) {
+			encerrarSerie(serie, "Ninguém", "Desconexão")
+			return
+		}
+
+		//Empate não conta vitória para nenhum dos jogadores
+		if batalha.venceu(serie.Jogador1) {
+			serie.Vitorias1++
+		} else if batalha.venceu(serie.Jogador2) {
+			serie.Vitorias2++
+		}
+
+		if serie.Vitorias1 >= necessarias || serie.Vitorias2 >= necessarias {
+			break
+		}
+
+		resposta := Resposta{Tipo: "Placar_Serie", Mensagem: placarSerie(serie)}
+		enviarParaJogadores(resposta, serie.Jogador1, serie.Jogador2)
+		time.Sleep(IntervaloSerie)
+	}
+
+	if serie.Vitorias1 > serie.Vitorias2 {
+		encerrarSerie(serie, serie.Jogador1, "Maioria das vitórias")
+	} else if serie.Vitorias2 > serie.Vitorias1 {
+		encerrarSerie(serie, serie.Jogador2, "Maioria das vitórias")
+	} else {
+		encerrarSerie(serie, "Ninguém", "Empate")
+	}
+}
+
+// Função para finalizar a série, enviando o resumo e registrando um único resultado
+func encerrarSerie(serie *Serie, vencedor, motivo string) {
+	muSeries.Lock()
+	delete(series, serie.Jogador1)
+	delete(series, serie.Jogador2)
+	muSeries.Unlock()
+
+	//Atualizar ranking antes de avisar os jogadores
+	registrarResultadoSerie(serie, vencedor)
+
+	resposta := Resposta{
+		Tipo:     "Fim_Serie",
+		Mensagem: fmt.Sprintf("Série melhor de %d encerrada! Jogador %s venceu (%s). %s", serie.Jogos, vencedor, motivo, placarSerie(serie)),
+	}
+	enviarParaJogadores(resposta, serie.Jogador1, serie.Jogador2)
+
+	//Log do servidor
+	color.Yellow("Série finalizada entre %s e %s, vencedor %s (%d x %d)", serie.Jogador1, serie.Jogador2, vencedor, serie.Vitorias1, serie.Vitorias2)
+}
+
+// Função para descrever o placar atual da série
+func placarSerie(serie *Serie) string {
+	return fmt.Sprintf("Placar: Jogador %s %d x %d Jogador %s", serie.Jogador1, serie.Vitorias1, serie.Vitorias2, serie.Jogador2)
+}
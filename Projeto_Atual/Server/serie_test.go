@@ -0,0 +1,58 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+// Função para montar decks fixos, assim a batalha não depende das coleções
+func decksTeste(ids ...string) map[string][]Tanque {
+	decks := make(map[string][]Tanque)
+	for _, id := range ids {
+		decks[id] = []Tanque{{Modelo: "A", Id_jogador: id, Vida: 10, Ataque: 5}}
+	}
+	return decks
+}
+
+func TestBatalhaDaSerieComPedidosSimultaneos(t *testing.T) {
+	batalhas = make(map[string]*Batalha)
+	serie := &Serie{Jogador1: "1", Jogador2: "2", Jogos: 3}
+	series = map[string]*Serie{"1": serie, "2": serie}
+	regras, _ := buscarRegras("")
+
+	//Metade dos pedidos vem da série e metade de fora dela, todos ao mesmo tempo para o mesmo par
+	const pedidos = 20
+	criadas := make(chan *Batalha, pedidos)
+	var wg sync.WaitGroup
+	for i := 0; i < pedidos; i++ {
+		daSerie := serie
+		if i%2 == 1 {
+			daSerie = nil
+		}
+		wg.Add(1)
+		go func(daSerie *Serie) {
+			defer wg.Done()
+			if batalha, err := novaBatalha(ModoDuelo, []string{"1", "2"}, regras, decksTeste("1", "2"), daSerie); err == nil {
+				criadas <- batalha
+			}
+		}(daSerie)
+	}
+	wg.Wait()
+	close(criadas)
+
+	if len(criadas) != 1 {
+		t.Fatalf("%d batalhas criadas, esperado 1", len(criadas))
+	}
+	batalha := <-criadas
+	if !batalha.EmSerie {
+		t.Error("batalha criada fora da série com os jogadores em série")
+	}
+	if batalhas["1"] != batalha || batalhas["2"] != batalha {
+		t.Error("batalha não ficou registrada para os dois jogadores")
+	}
+
+	//Com a batalha registrada nem a própria série cria outra
+	if _, err := novaBatalha(ModoDuelo, []string{"1", "2"}, regras, decksTeste("1", "2"), serie); err == nil {
+		t.Error("segunda batalha criada com a primeira em andamento")
+	}
+}
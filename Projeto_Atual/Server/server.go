@@ -3,9 +3,10 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"math/rand"
 	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,36 +15,51 @@ import (
 
 // Struct como modelo de requisição do cliente para servidor
 type Requisicao struct {
-	Tipo            string `json:"tipo"`
-	Id_remetente    string `json:"id_remetente"`
-	Id_destinatario string `json:"id_destinatario"`
-	Mensagem        string `json:"mensagem"`
-	Carta           Tanque `json:"carta"`
+	Tipo            string   `json:"tipo"`
+	Id_remetente    string   `json:"id_remetente"`
+	Id_destinatario string   `json:"id_destinatario"`
+	Mensagem        string   `json:"mensagem"`
+	Carta           Tanque   `json:"carta"`
+	Cartas          []Tanque `json:"cartas"`
+	Pedidas         []Tanque `json:"pedidas"` //Cartas pedidas ao outro jogador em uma troca
 }
 
 // Struct modelo de resposta do servidor para cliente
 type Resposta struct {
-	Tipo     string   `json:"tipo"`
-	Mensagem string   `json:"mensagem"`
-	Cartas   []Tanque `json:"cartas"`
+	Tipo         string            `json:"tipo"`
+	Mensagem     string            `json:"mensagem"`
+	Cartas       []Tanque          `json:"cartas"`
+	Decks        []Deck            `json:"decks"`
+	Ranking      []PosicaoRanking  `json:"ranking"`
+	Partidas     []Partida         `json:"partidas"`
+	Estatisticas *Estatisticas     `json:"estatisticas"`
+	Replay       *Replay           `json:"replay"`
+	Evento       *EventoReplay     `json:"evento"`
+	Batalhas     []ResumoBatalha   `json:"batalhas"`
+	Trocas       []Troca           `json:"trocas"`
+	Anuncios     []Anuncio         `json:"anuncios"`
+	Procedencia  []RegistroPosse   `json:"procedencia"`
+	Missoes      []ProgressoMissao `json:"missoes"`
+	Temporada    *Temporada        `json:"temporada"`
+	Torneios     []ResumoTorneio   `json:"torneios"`
+	Campanha     []EtapaCampanha   `json:"campanha"`
+	Chat         *MensagemChat     `json:"chat"`
+	Salas        []ResumoSala      `json:"salas"`
+	Amigos       []Amigo           `json:"amigos"`
+	Codigo       string            `json:"codigo"` //Código específico de alguns erros, como os de moderação
 }
 
 // Carta do jogo
 type Tanque struct {
-	Modelo     string `json:"modelo"`
-	Id_jogador string `json:"id_jogador"`
-	Vida       int    `json:"vida"`
-	Ataque     int    `json:"ataque"`
-}
-
-// Struct para dados de uma batalha
-type Batalha struct {
-	Jogador1         string
-	Jogador2         string
-	Canal1           chan Tanque
-	Canal2           chan Tanque
-	Encerramento     chan bool
-	EncerramentoOnce sync.Once
+	Modelo      string `json:"modelo"`
+	Id_jogador  string `json:"id_jogador"`
+	Vida        int    `json:"vida"`
+	Ataque      int    `json:"ataque"`
+	Instancia   int    `json:"instancia"`   //Id único da carta, 0 para cartas temporárias
+	Serie       int    `json:"serie"`       //Número de série entre as cartas cunhadas do mesmo modelo
+	Estrelas    int    `json:"estrelas"`    //Estrelas ganhas em fusões, começando em 1
+	Nivel       int    `json:"nivel"`       //Nível ganho com experiência de batalha, começando em 1
+	Experiencia int    `json:"experiencia"` //Experiência acumulada para o próximo nível
 }
 
 // Struct para requisição de Ping (UDP)
@@ -53,19 +69,17 @@ type Ping struct {
 
 // Variáveis do server
 var (
-	clientes      = make(map[string]net.Conn) //Map para guardar conexões através dos IDs
-	muClientes    sync.RWMutex                //Mutex para sincronização dos jogadores
-	pares         = make(map[string]string)   //Pares de jogadores conectados
-	muPares       sync.RWMutex                //Mutex para sincronização de jogadores pareados
-	idCounter     int                         //Contador do ID
-	pacoteCounter = 10                        //Contador de pacotes disponíveis
-	muPacote      sync.Mutex                  //Mutex para sincronização do estoques
-	batalhas      = make(map[string]*Batalha) //Map para guardar batalhas em andamento
-	muBatalhas    sync.RWMutex                //Mutex para sincronizar as batalhas
+	clientes   = make(map[string]net.Conn) //Map para guardar conexões através dos IDs
+	muClientes sync.RWMutex                //Mutex para sincronização dos jogadores
+	idCounter  int                         //Contador do ID
+	batalhas   = make(map[string]*Batalha) //Map para guardar batalhas em andamento
+	muBatalhas sync.RWMutex                //Mutex para sincronizar as batalhas
+	colecoes   = make(map[string][]Tanque) //Cartas adquiridas por cada jogador
+	muColecoes sync.RWMutex                //Mutex para sincronizar as coleções
 )
 
 // Pacote 1 de cartas
-var pacote_1 = []Tanque{
+var pacote_1 = []ModeloCarta{
 	//15 leves
 	{"M22 (Light)", "server", 50, 10},
 	{"M22 (Light)", "server", 50, 10},
@@ -112,6 +126,19 @@ const (
 func main() {
 	color.NoColor = false
 
+	//Duração das temporadas ranqueadas, ex: go run . -temporada 1h
+	duracaoTemporada := flag.Duration("temporada", DuracaoTemporada, "Duração de cada temporada ranqueada")
+	diretorioCampanha := flag.String("campanha", DiretorioCampanha, "Diretório com os arquivos das missões da campanha")
+	arquivoFiltro := flag.String("filtro", "", "Arquivo com as palavras proibidas no chat, uma por linha")
+	flag.StringVar(&senhaAdmin, "admin", "", "Senha para os jogadores se autenticarem como administradores")
+	flag.Parse()
+
+	//Filtro de palavras do chat
+	carregarFiltro(*arquivoFiltro)
+
+	//Missões da campanha lidas dos arquivos json
+	carregarCampanha(*diretorioCampanha)
+
 	//Criação de porta TCP
 	ln, err := net.Listen("tcp", ":8080")
 	if err != nil {
@@ -132,6 +159,15 @@ func main() {
 	//Inicia uma goroutine para lidar com as requisições de "Ping" (UDP)
 	go lidarPing(udpConn)
 
+	//Goroutine para liquidar os anúncios vencidos do mercado
+	go liquidarAnuncios()
+
+	//Goroutine para avisar os amigos das mudanças de presença
+	go vigiarPresencas()
+
+	//Primeira temporada ranqueada, encerrada por uma goroutine na data de fim
+	agendarTemporadas(*duracaoTemporada)
+
 	//Ouvir constantemente requisições de conexão
 	for {
 		conn, err := ln.Accept()
@@ -158,13 +194,16 @@ func criarConexao(conn net.Conn) {
 
 	resposta := Resposta{Tipo: "Criaçao_Id", Mensagem: id_cliente}
 	enviarResposta(conn, resposta)
+	criarSessao(conn, id_cliente)
+	concederBonusDiario(conn, id_cliente)
+	enviarTemporada(conn, id_cliente, "None")
 
 	//Ler constantemente coisas enviados pelo outro lado da conexão
 	reader := bufio.NewReader(conn)
 	for {
 		msg, err := reader.ReadBytes('\n')
 		if err != nil {
-			tratarDesconexao(id_cliente)
+			tratarDesconexao(id_cliente, conn)
 			return
 		}
 
@@ -178,62 +217,235 @@ func criarConexao(conn net.Conn) {
 
 		//Decodificar o tipo da requisição
 		switch requisicao.Tipo {
+		case "Reconectar":
+			//A conexão passa a usar o id antigo do jogador
+			if idAntigo, ok := reconectar(conn, id_cliente, requisicao.Mensagem); ok {
+				id_cliente = idAntigo
+			}
+
+		case "Encerrar_Sessao":
+			encerrarSessao(id_cliente)
+
 		case "Parear":
-			parearClientes(conn, requisicao.Id_remetente, requisicao.Id_destinatario)
+			parearClientes(conn, id_cliente, requisicao.Id_destinatario)
 
 		case "Mensagem":
-			transmitirMensagem(conn, requisicao.Id_remetente, requisicao.Id_destinatario, requisicao.Mensagem)
+			transmitirMensagem(conn, id_cliente, requisicao.Id_destinatario, requisicao.Mensagem)
+
+		case "Lobby":
+			enviarLobby(conn, id_cliente, requisicao.Mensagem)
+
+		case "Sussurro":
+			sussurrar(conn, id_cliente, requisicao.Id_destinatario, requisicao.Mensagem)
+
+		case "Apelido":
+			definirApelido(conn, id_cliente, requisicao.Mensagem)
+
+		case "Entrar_Sala":
+			entrarSala(conn, id_cliente, requisicao.Mensagem)
+
+		case "Sair_Sala":
+			sairSala(conn, id_cliente, requisicao.Mensagem)
+
+		case "Listar_Salas":
+			listarSalas(conn)
+
+		case "Mensagem_Sala":
+			enviarSala(conn, id_cliente, requisicao.Id_destinatario, requisicao.Mensagem)
+
+		case "Bloquear":
+			alterarBloqueio(conn, id_cliente, requisicao.Mensagem, true)
+
+		case "Desbloquear":
+			alterarBloqueio(conn, id_cliente, requisicao.Mensagem, false)
+
+		case "Bloqueados":
+			listarBloqueados(conn, id_cliente)
+
+		case "Admin":
+			autenticarAdmin(conn, id_cliente, requisicao.Mensagem)
+
+		case "Silenciar":
+			silenciarJogador(conn, id_cliente, requisicao.Mensagem)
+
+		case "Pedir_Amizade":
+			pedirAmizade(conn, id_cliente, requisicao.Mensagem)
+
+		case "Aceitar_Amizade":
+			responderAmizade(conn, id_cliente, requisicao.Mensagem, true)
+
+		case "Recusar_Amizade":
+			responderAmizade(conn, id_cliente, requisicao.Mensagem, false)
+
+		case "Remover_Amigo":
+			removerAmigo(conn, id_cliente, requisicao.Mensagem)
+
+		case "Amigos":
+			listarAmigos(conn, id_cliente)
 
 		case "Abrir_Pacote":
-			sortearCartas(conn, requisicao.Id_remetente)
+			sortearCartas(conn, id_cliente, requisicao.Mensagem)
+
+		case "Saldo":
+			enviarSaldo(conn, id_cliente)
+
+		case "Criar_Deck":
+			criarDeck(conn, id_cliente, requisicao.Mensagem, requisicao.Cartas)
+
+		case "Listar_Decks":
+			listarDecks(conn, id_cliente)
+
+		case "Selecionar_Deck":
+			selecionarDeck(conn, id_cliente, requisicao.Mensagem)
+
+		case "Listar_Regras":
+			listarRegras(conn)
 
 		case "Batalhar":
-			batalha := Batalha{
-				Jogador1:     requisicao.Id_remetente,
-				Jogador2:     requisicao.Id_destinatario,
-				Canal1:       make(chan Tanque),
-				Canal2:       make(chan Tanque),
-				Encerramento: make(chan bool),
+			//Regras escolhidas pelo nome na mensagem
+			regras, ok := buscarRegras(requisicao.Mensagem)
+			if !ok {
+				resposta.Tipo = "Erro"
+				resposta.Mensagem = fmt.Sprintf("Regras de batalha %s não existem", requisicao.Mensagem)
+				enviarResposta(conn, resposta)
+				break
 			}
-			muBatalhas.Lock()
-			batalhas[requisicao.Id_remetente] = &batalha
-			batalhas[requisicao.Id_destinatario] = &batalha
-			muBatalhas.Unlock()
-
-			go realizarBatalha(&batalha)
 
-		case "Próxima_Carta":
-			muBatalhas.RLock()
-			batalha, existe := batalhas[requisicao.Id_remetente]
-			muBatalhas.RUnlock()
+			if err := validarOponente(id_cliente, requisicao.Id_destinatario); err != nil {
+				resposta.Tipo = "Erro"
+				resposta.Mensagem = err.Error()
+				enviarResposta(conn, resposta)
+				break
+			}
 
-			if !existe {
+			batalha, err := novaBatalha(ModoDuelo, []string{id_cliente, requisicao.Id_destinatario}, regras, nil, nil)
+			if err != nil {
 				resposta.Tipo = "Erro"
-				resposta.Mensagem = "Você não está em uma batalha"
+				resposta.Mensagem = err.Error()
 				enviarResposta(conn, resposta)
-			} else {
-				//Verifica qual jogador é para mandar no canal correto
-				if requisicao.Id_remetente == batalha.Jogador1 {
-					select {
-					case batalha.Canal1 <- requisicao.Carta:
-						//Apenas envia
-					default:
-						color.Red("Canal1 cheio ou encerrado para %s", batalha.Jogador1)
-						batalha.Encerramento <- true
-					}
-				} else if requisicao.Id_remetente == batalha.Jogador2 {
-					select {
-					case batalha.Canal2 <- requisicao.Carta:
-						//Apenas envia
-					default:
-						color.Red("Canal2 cheio ou encerrado para %s", batalha.Jogador2)
-						select {
-						case batalha.Encerramento <- true:
-						default:
-						}
-					}
-				}
+				break
 			}
+			go realizarBatalha(batalha)
+
+		case "Batalhar_IA":
+			iniciarBatalhaIA(conn, id_cliente, requisicao.Mensagem)
+
+		case "Campanha":
+			enviarCampanha(conn, id_cliente)
+
+		case "Missao":
+			iniciarMissao(conn, id_cliente, requisicao.Mensagem)
+
+		case "Batalhar_Draft":
+			iniciarDraft(conn, id_cliente, requisicao.Id_destinatario, requisicao.Mensagem)
+
+		case "Batalhar_Selado":
+			iniciarSelado(conn, id_cliente, requisicao.Id_destinatario, requisicao.Mensagem)
+
+		case "Batalhar_Serie":
+			iniciarSerie(conn, id_cliente, requisicao.Id_destinatario, requisicao.Mensagem)
+
+		case "Batalhar_Equipes":
+			iniciarBatalhaEquipes(conn, id_cliente, requisicao.Mensagem)
+
+		case "Batalhar_Todos":
+			iniciarBatalhaTodos(conn, id_cliente, requisicao.Mensagem)
+
+		case "Ranking":
+			enviarRanking(conn, id_cliente, requisicao.Mensagem)
+
+		case "Historico":
+			enviarHistorico(conn, id_cliente, requisicao.Mensagem)
+
+		case "Estatisticas":
+			enviarEstatisticas(conn, id_cliente, requisicao.Mensagem)
+
+		case "Replay":
+			transmitirReplay(conn, id_cliente, requisicao.Mensagem)
+
+		case "Batalhas_Ativas":
+			listarBatalhasAtivas(conn)
+
+		case "Assistir":
+			assistirBatalha(conn, id_cliente, requisicao.Mensagem)
+
+		case "Parar_Assistir":
+			sairDeAssistir(conn, id_cliente)
+
+		case "Ver_Colecao":
+			verColecao(conn, id_cliente, requisicao.Id_destinatario)
+
+		case "Propor_Troca":
+			proporTroca(conn, id_cliente, requisicao.Id_destinatario, requisicao.Cartas, requisicao.Pedidas, 0)
+
+		case "Contrapropor_Troca":
+			contraProporTroca(conn, id_cliente, requisicao.Mensagem, requisicao.Cartas, requisicao.Pedidas)
+
+		case "Aceitar_Troca":
+			aceitarTroca(conn, id_cliente, requisicao.Mensagem)
+
+		case "Cancelar_Troca":
+			cancelarTroca(conn, id_cliente, requisicao.Mensagem)
+
+		case "Listar_Trocas":
+			listarTrocas(conn, id_cliente)
+
+		case "Fundir":
+			fundirCartas(conn, id_cliente, requisicao.Cartas)
+
+		case "Desmontar":
+			desmontarCartas(conn, id_cliente, requisicao.Cartas)
+
+		case "Fabricar":
+			fabricarCarta(conn, id_cliente, requisicao.Mensagem)
+
+		case "Catalogo":
+			enviarCatalogo(conn)
+
+		case "Temporada":
+			enviarTemporada(conn, id_cliente, requisicao.Mensagem)
+
+		case "Missoes":
+			enviarMissoes(conn, id_cliente)
+
+		case "Conquistas":
+			enviarConquistas(conn, id_cliente)
+
+		case "Procedencia":
+			enviarProcedencia(conn, requisicao.Mensagem)
+
+		case "Anunciar":
+			anunciarCarta(conn, id_cliente, requisicao.Mensagem, requisicao.Cartas)
+
+		case "Listar_Anuncios":
+			listarAnuncios(conn)
+
+		case "Comprar":
+			comprarAnuncio(conn, id_cliente, requisicao.Mensagem)
+
+		case "Dar_Lance":
+			darLance(conn, id_cliente, requisicao.Mensagem)
+
+		case "Retirar_Anuncio":
+			retirarAnuncio(conn, id_cliente, requisicao.Mensagem)
+
+		case "Criar_Torneio":
+			criarTorneio(conn, id_cliente, requisicao.Mensagem)
+
+		case "Inscrever":
+			inscreverTorneio(conn, id_cliente, requisicao.Mensagem)
+
+		case "Iniciar_Torneio":
+			iniciarTorneio(conn, id_cliente, requisicao.Mensagem)
+
+		case "Listar_Torneios":
+			listarTorneios(conn)
+
+		case "Sair_Grupo":
+			sairGrupo(conn, id_cliente)
+
+		case "Próxima_Carta", "Alvo":
+			receberEscolha(conn, id_cliente, requisicao.Tipo, requisicao.Mensagem)
 
 		default:
 			resposta.Tipo = "Erro"
@@ -249,303 +461,204 @@ func enviarResposta(conn net.Conn, resposta Resposta) {
 	conn.Write(append(resposta_json, '\n'))
 }
 
-// Função para parear 2 jodadores
+// Função para enviar uma resposta aos jogadores que ainda estão conectados, incluindo os bots em batalha
+func enviarParaJogadores(resposta Resposta, ids ...string) {
+	muClientes.RLock()
+	defer muClientes.RUnlock()
+	for _, id := range ids {
+		if conn, ok := clientes[id]; ok {
+			enviarResposta(conn, resposta)
+		} else if conn, ok := conexaoIA(id); ok {
+			enviarResposta(conn, resposta)
+		} else {
+			guardarPendente(id, resposta)
+		}
+	}
+}
+
+// Função para parear 2 jodadores, ou adicionar o destinatário ao grupo do remetente
 func parearClientes(conn net.Conn, id_remetente, id_destinatario string) {
 	var resposta Resposta
 
+	muClientes.RLock()
+	_, conectado := clientes[id_destinatario]
+	muClientes.RUnlock()
+
+	//Convite de quem foi bloqueado pelo destinatário é recusado
+	if err := verificarBloqueio(id_remetente, id_destinatario); err != nil {
+		enviarErroModeracao(conn, err)
+		return
+	}
+
+	//Bloquear acesso da variável de grupos durante a verificação e o pareamento
+	muGrupos.Lock()
+	grupo, remetenteEmGrupo := grupos[id_remetente]
+	_, destinatarioEmGrupo := grupos[id_destinatario]
+
 	if id_remetente == id_destinatario {
-		resposta.Tipo = "Erro"
 		resposta.Mensagem = "Id destinatário não pode ser igual ao Id remetente"
-		enviarResposta(conn, resposta)
-		return
-	} else if _, existe := clientes[id_destinatario]; !existe {
-		resposta.Tipo = "Erro"
+	} else if !conectado {
 		resposta.Mensagem = "Id destinatário não existe"
-		enviarResposta(conn, resposta)
-		return
-	} else if _, existe := pares[id_remetente]; existe {
-		resposta.Tipo = "Erro"
-		resposta.Mensagem = "Já existe um pareamento existente para o remetente"
-		enviarResposta(conn, resposta)
-		return
-	} else if _, existe := pares[id_destinatario]; existe {
-		resposta.Tipo = "Erro"
+	} else if destinatarioEmGrupo {
 		resposta.Mensagem = "Já existe um pareamento existente para o destinatário"
+	} else if remetenteEmGrupo && len(grupo.Membros) >= MaxMembrosGrupo {
+		resposta.Mensagem = fmt.Sprintf("O grupo já tem %d jogadores", MaxMembrosGrupo)
+	}
+	if resposta.Mensagem != "" {
+		muGrupos.Unlock()
+		resposta.Tipo = "Erro"
 		enviarResposta(conn, resposta)
 		return
 	}
 
-	//Bloquear acesso da variáveis de pares e jogadores para sincronização
-	muPares.Lock()
-	pares[id_remetente] = id_destinatario
-	pares[id_destinatario] = id_remetente
-	muPares.Unlock()
+	if !remetenteEmGrupo {
+		grupo = &Grupo{Lider: id_remetente, Membros: []string{id_remetente}}
+		grupos[id_remetente] = grupo
+	}
+	grupo.Membros = append(grupo.Membros, id_destinatario)
+	grupos[id_destinatario] = grupo
+	membros := append([]string(nil), grupo.Membros...)
+	muGrupos.Unlock()
 
 	resposta.Tipo = "Pareamento"
 	resposta.Mensagem = id_destinatario
 	enviarResposta(conn, resposta)
 
-	//Garantir leitura sincronizada entre goroutines que também lêem a variável
-	muClientes.RLock()
 	resposta.Mensagem = id_remetente
-	enviarResposta(clientes[id_destinatario], resposta)
-	muClientes.RUnlock()
+	enviarParaJogadores(resposta, id_destinatario)
+
+	notificarGrupo(membros)
 
 	//Log do server
-	color.Green("Pareamento entre %s e %s", id_remetente, id_destinatario)
+	color.Green("Pareamento entre %s e %s (grupo %s)", id_remetente, id_destinatario, strings.Join(membros, ", "))
 }
 
-// Função para mandar mensagem de um jogador para o outro pareado
+// Função para mandar mensagem de um jogador para outro do mesmo grupo
 func transmitirMensagem(conn net.Conn, id_remetente, idDestinatario, mensagem string) {
-	//Garantir leitura sincronizada entre goroutines que também lêem a variável
-	muPares.RLock()
-	idPar, existe := pares[id_remetente]
-	muPares.RUnlock()
-
 	var resposta Resposta
-	if idPar != idDestinatario || !existe {
+	if id_remetente == idDestinatario || !mesmoGrupo(id_remetente, idDestinatario) {
 		resposta.Tipo = "Erro"
-		resposta.Mensagem = "Id do destinatário difente da conexão existente ou não existe conexão"
+		resposta.Mensagem = "Id do destinatário não faz parte do seu grupo ou não existe conexão"
 		enviarResposta(conn, resposta)
 		return
 	}
+	if err := validarTexto(mensagem); err != nil {
+		enviarErroChat(conn, err.Error())
+		return
+	}
+	if err := verificarBloqueio(id_remetente, idDestinatario); err != nil {
+		enviarErroModeracao(conn, err)
+		return
+	}
+	if err := verificarMensagem(id_remetente, mensagem); err != nil {
+		enviarErroModeracao(conn, err)
+		return
+	}
 
-	//Garantir leitura sincronizada entre goroutines que também lêem a variável
-	muClientes.RLock()
-	idParConn := clientes[idPar]
-	muClientes.RUnlock()
-
+	resposta = novaMensagemChat(id_remetente, CanalGrupo, mensagem)
 	resposta.Tipo = "Mensagem"
-	resposta.Mensagem = mensagem
-	enviarResposta(idParConn, resposta)
+	enviarParaJogadores(resposta, idDestinatario)
+	registrarEventoMissao(id_remetente, "Mensagem", 1) //Mensagem ao oponente do grupo conta para as missões
 
 	//Log do servidor
 	color.Yellow("Mensagem de %s >>> %s", id_remetente, idDestinatario)
 }
 
-// Função de sortear cartas do pacote escolhido
-func sortearCartas(conn net.Conn, id string) {
-	//Cria um gerador aleatório independente usando tempo da chamada da função
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	//Bloquear acesso ao contador de pacotes disponíveis
-	muPacote.Lock()
-	defer muPacote.Unlock()
-
+// Função de sortear cartas do pacote escolhido, descontando o preço do saldo do jogador
+func sortearCartas(conn net.Conn, id, nomePacote string) {
 	var resposta Resposta
-	if pacoteCounter <= 0 {
+	tipo, existe := buscarTipoPacote(nomePacote)
+	if !existe {
 		resposta.Tipo = "Erro"
-		resposta.Mensagem = "Não há mais pacotes disponíveis"
+		resposta.Mensagem = fmt.Sprintf("Pacote %s não existe", nomePacote)
 		enviarResposta(conn, resposta)
 		return
 	}
 
-	pacoteCounter--
-
-	//Sorteia 5 índices usando o gerador independente
-	n := len(pacote_1)
-	indices := r.Perm(n)[:5]
-
-	cartasSorteadas := make([]Tanque, 0, 5)
-
-	for _, i := range indices {
-		cartasSorteadas = append(cartasSorteadas, pacote_1[i])
+	//Sem estoque global, o preço em moedas limita as compras de cada jogador.
+	//Conferir e descontar o preço de uma vez, compras simultâneas não deixam o saldo negativo
+	muSaldos.Lock()
+	if saldos[id] < tipo.Preco {
+		saldo := saldos[id]
+		muSaldos.Unlock()
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Saldo insuficiente: pacote %s custa %d moedas e você tem %d", tipo.Nome, tipo.Preco, saldo)
+		enviarResposta(conn, resposta)
+		return
 	}
+	saldos[id] -= tipo.Preco
+	saldo := saldos[id]
+	muSaldos.Unlock()
 
-	for i := range cartasSorteadas { //Trocar ID para o do jogador
-		cartasSorteadas[i].Id_jogador = id
-	}
+	cartasSorteadas := sortearDoPacote(tipo, id)
+
+	//Guardar cartas na coleção do jogador
+	muColecoes.Lock()
+	colecoes[id] = append(colecoes[id], cartasSorteadas...)
+	muColecoes.Unlock()
 
 	resposta.Tipo = "Sorteio"
-	resposta.Mensagem = "Sorteio realizado com sucesso"
+	resposta.Mensagem = fmt.Sprintf("Pacote %s comprado por %d moedas. Saldo: %d moedas", tipo.Nome, tipo.Preco, saldo)
 	resposta.Cartas = cartasSorteadas
 
 	enviarResposta(conn, resposta)
+	registrarEventoMissao(id, "Pacote", 1)
 
 	//Log do servidor
-	color.Cyan("Jogador %s comprou cartas", id)
+	color.Cyan("Jogador %s comprou o pacote %s", id, tipo.Nome)
 }
 
-// Função para tratar desconexão de jogador
-func tratarDesconexao(idDesconectado string) {
-	//Atualizar lista e jogadores conectados
+// Função para tratar desconexão de jogador, que ainda pode reconectar enquanto a sessão existir
+func tratarDesconexao(idDesconectado string, conn net.Conn) {
+	//Atualizar lista e jogadores conectados, ignorando conexões já substituídas por uma reconexão
 	muClientes.Lock()
-	if conn, ok := clientes[idDesconectado]; ok {
-		conn.Close()
-		delete(clientes, idDesconectado)
+	if atual, ok := clientes[idDesconectado]; !ok || atual != conn {
+		muClientes.Unlock()
+		return
 	}
+	conn.Close()
+	delete(clientes, idDesconectado)
 	muClientes.Unlock()
 
-	//Atualizar lista de jogadores pareados se necessário
-	muPares.Lock()
-	if idPar, ok := pares[idDesconectado]; ok {
-		muClientes.RLock()
-		conn2 := clientes[idPar]
-		resposta := Resposta{Tipo: "Desconexão", Mensagem: "Jogador desconectou"}
-		enviarResposta(conn2, resposta)
-		muClientes.RUnlock()
-
-		delete(pares, idDesconectado)
-		delete(pares, idPar)
-	}
-	muPares.Unlock()
+	//Retirar o jogador da lista de espectadores se estiver assistindo
+	pararDeAssistir(idDesconectado, false)
 
-	//Atualizar lista batalhas se existirem
-	muBatalhas.Lock()
-	if batalhaExistente, ok := batalhas[idDesconectado]; ok {
-		select {
-		case batalhaExistente.Encerramento <- true:
-		default:
-		}
-		delete(batalhas, idDesconectado)
-		delete(batalhas, batalhaExistente.Jogador2)
+	//Grupo e batalha esperam o jogador durante o tempo de reconexão
+	if !iniciarAusencia(idDesconectado) {
+		finalizarDesconexao(idDesconectado)
 	}
-	muBatalhas.Unlock()
-
-	color.Magenta("Jogador %s desconectou", idDesconectado)
 }
 
-// Função para realizar partida/batalha entre jogadores
-func realizarBatalha(batalha *Batalha) {
-	color.Yellow("Iniciando batalha entre %s e %s", batalha.Jogador1, batalha.Jogador2)
-
-	//Pegar conexão de cada jogador para não dar RLock e RUnlock várias vezes
-	muClientes.RLock()
-	connJogador1 := clientes[batalha.Jogador1]
-	connJogador2 := clientes[batalha.Jogador2]
-	muClientes.RUnlock()
-
-	//Envio de início de batalha para os 2 jogadores
-	respostaInicial := Resposta{Tipo: "Inicio_Batalha", Mensagem: batalha.Jogador2}
-	enviarResposta(connJogador1, respostaInicial) //Jogador 1
-
-	respostaInicial.Mensagem = batalha.Jogador1
-	enviarResposta(connJogador2, respostaInicial) //Jogador 2
+// Função para retirar definitivamente o jogador do grupo e da batalha
+func finalizarDesconexao(idDesconectado string) {
+	//Trocas pendentes do jogador são canceladas sem mexer nas coleções
+	cancelarTrocasDe(idDesconectado)
 
-	time.Sleep(1 * time.Second)
+	//Salas de chat e apelido ficam livres
+	limparChat(idDesconectado)
+	limparModeracao(idDesconectado)
 
-	//Estado inicial de partida
-	turno := 0
-	indice1, indice2 := 0, 0
-	var carta1, carta2 *Tanque
+	//Amizades ficam durante o tempo de reconexão, os amigos já receberam a presença Offline
+	limparAmizades(idDesconectado)
 
-	for {
-		select {
-		//Canal para caso ocorra desconexão de um jogador
-		case <-batalha.Encerramento:
-			color.Red("Batalha encerrada à força!")
-			encerrarBatalha(batalha, "Ninguém", "Ninguém", "Desconexão/força")
-			return
-		default:
-		}
-		//Verificar se existe carta viva do jogador 1
-		if carta1 == nil {
-			if indice1 >= 5 { //Verificar se jogador perdeu por usar 5 cartas por partida
-				encerrarBatalha(batalha, batalha.Jogador2, batalha.Jogador1, "Sem cartas restantes do oponente")
-				return
-			}
-
-			resposta := Resposta{Tipo: "Enviar_Próxima_Carta", Mensagem: fmt.Sprintf("%d", indice1)}
-			enviarResposta(connJogador1, resposta)
-
-			novaCarta, ok := esperarCarta(batalha.Canal1, 10*time.Second)
-			if !ok {
-				encerrarBatalha(batalha, batalha.Jogador2, batalha.Jogador1, "Timeout")
-				return
-			}
-
-			carta1 = novaCarta
-			indice1++
-		}
-
-		//Verificar se existe carta viva do jogador 2
-		if carta2 == nil {
-			if indice2 >= 5 { //Verificar se jogador perdeu por usar 5 cartas por partida
-				encerrarBatalha(batalha, batalha.Jogador1, batalha.Jogador2, "Sem cartas restantes do oponente")
-				return
-			}
-
-			resposta := Resposta{Tipo: "Enviar_Próxima_Carta", Mensagem: fmt.Sprintf("%d", indice2)}
-			enviarResposta(connJogador2, resposta)
-
-			novaCarta, ok := esperarCarta(batalha.Canal2, 10*time.Second)
-			if !ok {
-				encerrarBatalha(batalha, batalha.Jogador1, batalha.Jogador2, "Timeout")
-				return
-			}
-
-			carta2 = novaCarta
-			indice2++
-		}
-
-		var respostaTurno Resposta
-		if turno%2 == 0 { //Se for turno par, jogador 1 joga
-			carta2.Vida -= carta1.Ataque
-			respostaTurno.Mensagem = fmt.Sprintf("Jogador 1 jogou no turno %d", turno)
-		} else { //Turno ímpar, jogador 2 joga
-			carta1.Vida -= carta2.Ataque
-			respostaTurno.Mensagem = fmt.Sprintf("Jogador 2 jogou no turno %d", turno)
-		}
-
-		respostaTurno.Tipo = "Turno_Realizado"
-		respostaTurno.Cartas = []Tanque{*carta1, *carta2}
-		enviarResposta(connJogador1, respostaTurno)
-		enviarResposta(connJogador2, respostaTurno)
-
-		//Verificar se vida de cada carta foi reduzida a zero ou menos
-		if carta1.Vida <= 0 {
-			carta1 = nil
-		}
-		if carta2.Vida <= 0 {
-			carta2 = nil
-		}
-
-		turno++
-		time.Sleep(1 * time.Second)
-	}
-}
-
-// Função de timeout para espera de carta
-func esperarCarta(canal chan Tanque, tempo time.Duration) (*Tanque, bool) {
-	timeout := time.After(tempo)
-	select {
-	case c := <-canal:
-		return &c, true
-	case <-timeout:
-		return nil, false
+	//Atualizar grupo do jogador se necessário
+	restantes, desfeito := removerDoGrupo(idDesconectado)
+	if desfeito {
+		resposta := Resposta{Tipo: "Desconexão", Mensagem: "Jogador desconectou"}
+		enviarParaJogadores(resposta, restantes...)
+	} else if len(restantes) > 0 {
+		notificarGrupo(restantes)
 	}
-}
 
-// Função centralizada para finalizar corretamente uma batalha(fechar canais e atualizar map)
-func encerrarBatalha(batalha *Batalha, vencedor, perdedor string, motivo string) {
-	//Remover batalha do map
+	//Atualizar lista batalhas se existirem, o jogador é eliminado no seu próximo pedido de carta
 	muBatalhas.Lock()
-	delete(batalhas, batalha.Jogador1)
-	delete(batalhas, batalha.Jogador2)
-	muBatalhas.Unlock()
-
-	//Notificar para as conexões existentes a mensagem e fim de partida
-	var resposta Resposta
-	resposta.Tipo = "Fim_Batalha"
-	resposta.Mensagem = fmt.Sprintf("Batalha encerrada! Jogador %s venceu (%s).", vencedor, motivo)
-
-	muClientes.RLock()
-	if conn1, ok := clientes[batalha.Jogador1]; ok {
-		enviarResposta(conn1, resposta)
-	}
-	if conn2, ok := clientes[batalha.Jogador2]; ok {
-		enviarResposta(conn2, resposta)
+	if batalhaExistente, ok := batalhas[idDesconectado]; ok {
+		batalhaExistente.Desconectados = append(batalhaExistente.Desconectados, idDesconectado)
+		delete(batalhas, idDesconectado)
 	}
-	muClientes.RUnlock()
-
-	//Fechar canais com segurança
-	batalha.EncerramentoOnce.Do(func() {
-		close(batalha.Canal1)
-		close(batalha.Canal2)
-		close(batalha.Encerramento)
-	})
+	muBatalhas.Unlock()
 
-	//Log do servidor
-	color.Yellow("Batalha finalizada entre %s (vencedor) e %s (perdedor)", vencedor, perdedor)
+	color.Magenta("Jogador %s desconectou", idDesconectado)
 }
 
 // Função para lidar com requisições "Ping"
@@ -578,3 +691,13 @@ func lidarPing(conn net.PacketConn) {
 		color.Yellow("Enviado pong para %s", addr.String())
 	}
 }
+
+// Função para verificar se o id está na lista
+func contem(lista []string, id string) bool {
+	for _, item := range lista {
+		if item == id {
+			return true
+		}
+	}
+	return false
+}
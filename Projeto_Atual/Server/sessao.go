@@ -0,0 +1,255 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Sessão de um jogador, usada para reconectar depois de uma queda de conexão
+type Sessao struct {
+	Token     string
+	Ausente   bool        //Conexão caiu e o jogador ainda pode reconectar
+	Expirada  bool        //Tempo de reconexão acabou, a desconexão está sendo finalizada
+	Pendentes []Resposta  //Respostas enviadas enquanto o jogador estava ausente
+	Expiracao *time.Timer //Timer do fim do tempo de reconexão
+}
+
+// Variáveis das sessões
+var (
+	sessoes   = make(map[string]*Sessao) //Sessão de cada jogador
+	tokens    = make(map[string]string)  //Id do jogador de cada token
+	muSessoes sync.Mutex                 //Mutex para sincronizar as sessões
+)
+
+// Constantes da reconexão
+const (
+	TempoReconexao       = 30 * time.Second       //Tempo que o jogador tem para reconectar
+	IntervaloVerificacao = 200 * time.Millisecond //Intervalo para verificar se o jogador voltou
+	MaxPendentes         = 200                    //Quantidade máxima de respostas guardadas
+)
+
+// Função para gerar um token aleatório de sessão
+func gerarToken() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// Função para criar a sessão do jogador recém conectado e enviar o token
+func criarSessao(conn net.Conn, id string) {
+	sessao := &Sessao{Token: gerarToken()}
+
+	muSessoes.Lock()
+	sessoes[id] = sessao
+	tokens[sessao.Token] = id
+	muSessoes.Unlock()
+
+	enviarResposta(conn, Resposta{Tipo: "Sessao", Mensagem: sessao.Token})
+}
+
+// Função para encerrar a sessão por vontade do jogador, a próxima desconexão é finalizada na hora
+func encerrarSessao(id string) {
+	muSessoes.Lock()
+	defer muSessoes.Unlock()
+	if sessao, existe := sessoes[id]; existe {
+		delete(tokens, sessao.Token)
+		delete(sessoes, id)
+	}
+}
+
+// Função para marcar o jogador como ausente e iniciar o tempo de reconexão.
+// Retorna false se o jogador não tem sessão e a desconexão deve ser finalizada imediatamente
+func iniciarAusencia(id string) bool {
+	muSessoes.Lock()
+	sessao, existe := sessoes[id]
+	if !existe {
+		muSessoes.Unlock()
+		return false
+	}
+	sessao.Ausente = true
+	sessao.Pendentes = nil
+	sessao.Expiracao = time.AfterFunc(TempoReconexao, func() { expirarSessao(id) })
+	muSessoes.Unlock()
+
+	resposta := Resposta{
+		Tipo:     "Jogador_Ausente",
+		Mensagem: fmt.Sprintf("Jogador %s caiu, aguardando reconexão por até %ds", id, int(TempoReconexao.Seconds())),
+	}
+	enviarParaJogadores(resposta, outrosEnvolvidos(id)...)
+
+	//Log do servidor
+	color.Magenta("Jogador %s caiu, aguardando reconexão", id)
+	return true
+}
+
+// Função chamada quando o tempo de reconexão acaba sem o jogador voltar
+func expirarSessao(id string) {
+	muSessoes.Lock()
+	sessao, existe := sessoes[id]
+	if !existe || !sessao.Ausente {
+		muSessoes.Unlock()
+		return
+	}
+	sessao.Expirada = true
+	muSessoes.Unlock()
+
+	finalizarDesconexao(id)
+
+	//A sessão só some depois da desconexão finalizada, quem espera o jogador vê a eliminação
+	muSessoes.Lock()
+	delete(tokens, sessao.Token)
+	delete(sessoes, id)
+	muSessoes.Unlock()
+}
+
+// Função para guardar uma resposta enviada ao jogador ausente.
+// Pedidos de escolha não são guardados, a batalha pede novamente quando o jogador volta
+func guardarPendente(id string, resposta Resposta) {
+	if resposta.Tipo == "Enviar_Próxima_Carta" || resposta.Tipo == "Escolher_Alvo" {
+		return
+	}
+
+	muSessoes.Lock()
+	defer muSessoes.Unlock()
+	if sessao, existe := sessoes[id]; existe && sessao.Ausente && len(sessao.Pendentes) < MaxPendentes {
+		sessao.Pendentes = append(sessao.Pendentes, resposta)
+	}
+}
+
+// Função para verificar se o jogador está ausente esperando reconexão
+func estaAusente(id string) bool {
+	muSessoes.Lock()
+	defer muSessoes.Unlock()
+	sessao, existe := sessoes[id]
+	return existe && sessao.Ausente
+}
+
+// Função para esperar o jogador ausente voltar. Retorna false se o tempo de reconexão acabou
+func aguardarRetorno(id string) bool {
+	for {
+		muSessoes.Lock()
+		sessao, existe := sessoes[id]
+		ausente := existe && sessao.Ausente
+		muSessoes.Unlock()
+
+		if !existe {
+			return false
+		}
+		if !ausente {
+			return true
+		}
+		time.Sleep(IntervaloVerificacao)
+	}
+}
+
+// Função para pausar a batalha enquanto o participante estiver ausente.
+// Retorna true se o jogador voltou e a batalha pode continuar com ele
+func (b *Batalha) pausarSeAusente(id string) bool {
+	if !estaAusente(id) {
+		return false
+	}
+
+	b.transmitir(Resposta{Tipo: "Batalha_Pausada", Mensagem: fmt.Sprintf("Jogador %s caiu, batalha pausada por até %ds", id, int(TempoReconexao.Seconds()))})
+	voltou := aguardarRetorno(id)
+	if voltou {
+		b.transmitir(Resposta{Tipo: "Batalha_Retomada", Mensagem: fmt.Sprintf("Jogador %s voltou, batalha retomada", id)})
+	} else {
+		b.transmitir(Resposta{Tipo: "Batalha_Retomada", Mensagem: fmt.Sprintf("Jogador %s não voltou a tempo", id)})
+	}
+	return voltou
+}
+
+// Função para pausar a batalha até todos os participantes ausentes voltarem ou serem desconectados
+func (b *Batalha) aguardarAusentes() {
+	for _, p := range b.Participantes {
+		if !p.Eliminado {
+			b.pausarSeAusente(p.Id)
+		}
+	}
+}
+
+// Função para reconectar o jogador usando o token da sessão, a conexão nova assume o id antigo.
+// Retorna o id antigo se a reconexão deu certo
+func reconectar(conn net.Conn, idAtual, token string) (string, bool) {
+	token = strings.TrimSpace(token)
+
+	//Mesma ordem de bloqueio que enviarParaJogadores, assim nenhuma resposta fica entre as pendentes e as novas
+	muClientes.Lock()
+	muSessoes.Lock()
+
+	idAntigo, existe := tokens[token]
+	sessao := sessoes[idAntigo]
+	if !existe || idAntigo == idAtual || sessao.Expirada {
+		muSessoes.Unlock()
+		muClientes.Unlock()
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: "Sessão inválida ou expirada"})
+		return "", false
+	}
+
+	//Conexão antiga ainda aberta (queda não detectada), a nova assume o lugar
+	if antiga, ok := clientes[idAntigo]; ok {
+		antiga.Close()
+	}
+	if sessao.Expiracao != nil {
+		sessao.Expiracao.Stop()
+	}
+	sessao.Ausente = false
+	pendentes := sessao.Pendentes
+	sessao.Pendentes = nil
+
+	//Descartar o id temporário da conexão nova
+	if temporaria, ok := sessoes[idAtual]; ok {
+		delete(tokens, temporaria.Token)
+		delete(sessoes, idAtual)
+	}
+	delete(clientes, idAtual)
+	clientes[idAntigo] = conn
+
+	enviarResposta(conn, Resposta{Tipo: "Reconectado", Mensagem: idAntigo})
+	for _, resposta := range pendentes {
+		enviarResposta(conn, resposta)
+	}
+
+	muSessoes.Unlock()
+	muClientes.Unlock()
+
+	//Estado do grupo para o jogador e aviso para os outros
+	if membros := membrosGrupo(idAntigo); len(membros) > 0 {
+		notificarGrupo(membros)
+	}
+	resposta := Resposta{Tipo: "Jogador_Reconectado", Mensagem: fmt.Sprintf("Jogador %s reconectou", idAntigo)}
+	enviarParaJogadores(resposta, outrosEnvolvidos(idAntigo)...)
+
+	//Log do servidor
+	color.Green("Jogador %s reconectou com %d respostas pendentes", idAntigo, len(pendentes))
+	return idAntigo, true
+}
+
+// Função para listar os outros membros do grupo e participantes da batalha do jogador
+func outrosEnvolvidos(id string) []string {
+	envolvidos := make([]string, 0)
+	for _, membro := range membrosGrupo(id) {
+		if membro != id {
+			envolvidos = append(envolvidos, membro)
+		}
+	}
+
+	muBatalhas.RLock()
+	batalha, existe := batalhas[id]
+	muBatalhas.RUnlock()
+	if existe {
+		for _, participante := range batalha.ids() {
+			if participante != id && !contem(envolvidos, participante) {
+				envolvidos = append(envolvidos, participante)
+			}
+		}
+	}
+	return envolvidos
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReconexaoEntregaCadaRespostaUmaVez(t *testing.T) {
+	clientes = make(map[string]net.Conn)
+	grupos = make(map[string]*Grupo)
+	batalhas = make(map[string]*Batalha)
+	sessoes = map[string]*Sessao{"1": {Token: "abc", Ausente: true}}
+	tokens = map[string]string{"abc": "1"}
+
+	//Conexão nova do jogador, lida até fechar
+	servidor, cliente := net.Pipe()
+	defer servidor.Close()
+	const envios = 50
+	recebidas := make(chan Resposta, envios+1)
+	go func() {
+		decoder := json.NewDecoder(cliente)
+		for {
+			var resposta Resposta
+			if decoder.Decode(&resposta) != nil {
+				return
+			}
+			recebidas <- resposta
+		}
+	}()
+
+	//Respostas enviadas durante a reconexão vão para as pendentes ou direto para a conexão nova, nunca para as duas
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < envios; i++ {
+			enviarParaJogadores(Resposta{Tipo: "Teste", Mensagem: strconv.Itoa(i)}, "1")
+		}
+	}()
+	idAntigo, ok := reconectar(servidor, "2", "abc")
+	wg.Wait()
+
+	if !ok || idAntigo != "1" {
+		t.Fatalf("reconectar = %q, %v, esperado \"1\", true", idAntigo, ok)
+	}
+
+	esperadas := []string{"1"}
+	for i := 0; i < envios; i++ {
+		esperadas = append(esperadas, strconv.Itoa(i))
+	}
+	for i, esperada := range esperadas {
+		select {
+		case resposta := <-recebidas:
+			if resposta.Mensagem != esperada || (i == 0 && resposta.Tipo != "Reconectado") {
+				t.Fatalf("resposta %d é %s %q, esperado %q", i, resposta.Tipo, resposta.Mensagem, esperada)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("só %d de %d respostas chegaram", i, len(esperadas))
+		}
+	}
+
+	muSessoes.Lock()
+	defer muSessoes.Unlock()
+	if sessao := sessoes["1"]; sessao.Ausente || len(sessao.Pendentes) > 0 {
+		t.Errorf("sessão continua ausente ou com %d pendentes", len(sessao.Pendentes))
+	}
+	if clientes["1"] != servidor {
+		t.Error("conexão nova não assumiu o id antigo")
+	}
+	if _, existe := clientes["2"]; existe {
+		t.Error("id temporário da conexão nova continua registrado")
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Temporada ranqueada com data de início e fim
+type Temporada struct {
+	Numero int       `json:"numero"`
+	Nome   string    `json:"nome"`
+	Inicio time.Time `json:"inicio"`
+	Fim    time.Time `json:"fim"`
+}
+
+// Temporada encerrada com o ranking final dos jogadores que participaram
+type TemporadaArquivada struct {
+	Temporada Temporada
+	Ranking   []PosicaoRanking
+}
+
+// Tier de recompensa pelo rating no fim da temporada
+type Tier struct {
+	Nome         string
+	RatingMinimo int
+	Moedas       int
+	Modelo       string //Modelo exclusivo dado como recompensa, vazio se não houver
+}
+
+// Variáveis das temporadas
+var (
+	temporadaAtual       Temporada
+	temporadasArquivadas []TemporadaArquivada
+	muTemporadas         sync.Mutex //Mutex para sincronizar as temporadas
+)
+
+// Duração padrão de uma temporada, pode ser trocada pela flag -temporada
+const DuracaoTemporada = 7 * 24 * time.Hour
+
+// Nomes usados pelas temporadas, em ciclo
+var nomesTemporadas = []string{"Blitz", "Kursk", "Normandia", "Ardenas", "El Alamein", "Stalingrado"}
+
+// Tiers do maior para o menor rating
+var tiers = []Tier{
+	{"Diamante", 1400, 500, "E-100 (Heavy)"},
+	{"Ouro", 1300, 300, "Sherman Jumbo (Medium)"},
+	{"Prata", 1200, 150, "M24 Chaffee (Light)"},
+	{"Bronze", 0, 50, ""},
+}
+
+// Modelos exclusivos das recompensas de temporada, fora dos pacotes e da fabricação
+var modelosExclusivos = []ModeloCarta{
+	{"M24 Chaffee (Light)", "server", 62, 16},
+	{"Sherman Jumbo (Medium)", "server", 130, 30},
+	{"E-100 (Heavy)", "server", 300, 60},
+}
+
+// Função para criar a temporada com o número informado
+func novaTemporada(numero int, inicio time.Time, duracao time.Duration) Temporada {
+	return Temporada{
+		Numero: numero,
+		Nome:   nomesTemporadas[(numero-1)%len(nomesTemporadas)],
+		Inicio: inicio,
+		Fim:    inicio.Add(duracao),
+	}
+}
+
+// Função para iniciar a primeira temporada e a goroutine que encerra cada uma na data de fim
+func agendarTemporadas(duracao time.Duration) {
+	//Duração não positiva encerraria as temporadas sem parar
+	if duracao <= 0 {
+		color.Yellow("Duração de temporada %s inválida, usando %s", duracao, DuracaoTemporada)
+		duracao = DuracaoTemporada
+	}
+
+	muTemporadas.Lock()
+	temporadaAtual = novaTemporada(1, time.Now(), duracao)
+	color.Green("Temporada %d (%s) iniciada, termina em %s", temporadaAtual.Numero, temporadaAtual.Nome, temporadaAtual.Fim.Format("02/01 15:04"))
+	muTemporadas.Unlock()
+
+	go func() {
+		for {
+			muTemporadas.Lock()
+			fim := temporadaAtual.Fim
+			muTemporadas.Unlock()
+
+			time.Sleep(time.Until(fim))
+			encerrarTemporada(duracao)
+		}
+	}()
+}
+
+// Função para encerrar a temporada: arquivar o ranking, aplicar o reset parcial, pagar os tiers e iniciar a próxima
+func encerrarTemporada(duracao time.Duration) {
+	muTemporadas.Lock()
+	encerrada := temporadaAtual
+
+	//Ranking final e reset com muRatings bloqueado, nenhum resultado fica entre os dois
+	muRatings.Lock()
+	participantes := make([]PosicaoRanking, 0)
+	for _, posicao := range montarTabelaRanking() {
+		if posicao.Vitorias+posicao.Derrotas+posicao.Empates > 0 {
+			posicao.Posicao = len(participantes) + 1
+			participantes = append(participantes, posicao)
+		}
+	}
+	for _, r := range ratings {
+		r.Pontos = RatingInicial + (r.Pontos-RatingInicial)/2 //Reset parcial, metade da distância para o inicial
+		r.Vitorias, r.Derrotas, r.Empates = 0, 0, 0
+	}
+	muRatings.Unlock()
+
+	temporadasArquivadas = append(temporadasArquivadas, TemporadaArquivada{Temporada: encerrada, Ranking: participantes})
+	temporadaAtual = novaTemporada(encerrada.Numero+1, time.Now(), duracao)
+	nova := temporadaAtual
+	muTemporadas.Unlock()
+
+	for _, posicao := range participantes {
+		pagarTier(encerrada, posicao)
+	}
+
+	//Aviso da temporada nova para todos os jogadores conectados
+	muClientes.RLock()
+	ids := make([]string, 0, len(clientes))
+	for id := range clientes {
+		ids = append(ids, id)
+	}
+	muClientes.RUnlock()
+	enviarParaJogadores(respostaTemporada(nova, "Nova temporada iniciada! "), ids...)
+
+	//Log do servidor
+	color.Green("Temporada %d encerrada com %d participantes, temporada %d (%s) iniciada", encerrada.Numero, len(participantes), nova.Numero, nova.Nome)
+}
+
+// Função para pagar a recompensa do tier do jogador no fim da temporada
+func pagarTier(temporada Temporada, posicao PosicaoRanking) {
+	tier := tiers[len(tiers)-1]
+	for _, t := range tiers {
+		if posicao.Rating >= t.RatingMinimo {
+			tier = t
+			break
+		}
+	}
+
+	saldo := creditarMoedas(posicao.Id, tier.Moedas)
+	mensagem := fmt.Sprintf("Temporada %d (%s) encerrada! Você terminou em %dº com rating %d no tier %s e ganhou %d moedas. Saldo: %d moedas",
+		temporada.Numero, temporada.Nome, posicao.Posicao, posicao.Rating, tier.Nome, tier.Moedas, saldo)
+
+	var cartas []Tanque
+	if modelo, existe := buscarModelo(tier.Modelo); existe {
+		carta := cunharCarta(modelo, posicao.Id, fmt.Sprintf("Recompensa da temporada %d (%s)", temporada.Numero, tier.Nome))
+		muColecoes.Lock()
+		colecoes[posicao.Id] = append(colecoes[posicao.Id], carta)
+		muColecoes.Unlock()
+		cartas = []Tanque{carta}
+		mensagem += fmt.Sprintf(", além do tanque exclusivo %s", carta.Modelo)
+	}
+	enviarParaJogadores(Resposta{Tipo: "Recompensa_Temporada", Mensagem: mensagem, Cartas: cartas}, posicao.Id)
+}
+
+// Função para montar a resposta com os dados da temporada e o tempo restante
+func respostaTemporada(temporada Temporada, prefixo string) Resposta {
+	restante := time.Until(temporada.Fim).Round(time.Second)
+	return Resposta{
+		Tipo:      "Temporada",
+		Mensagem:  fmt.Sprintf("%sTemporada %d (%s), termina em %s (%s restantes)", prefixo, temporada.Numero, temporada.Nome, temporada.Fim.Format("02/01 15:04"), restante),
+		Temporada: &temporada,
+	}
+}
+
+// Função para enviar a temporada atual, ou o ranking arquivado da temporada informada
+func enviarTemporada(conn net.Conn, id, mensagem string) {
+	mensagem = strings.TrimSpace(mensagem)
+	if mensagem == "" || mensagem == "None" {
+		muTemporadas.Lock()
+		atual := temporadaAtual
+		muTemporadas.Unlock()
+		enviarResposta(conn, respostaTemporada(atual, ""))
+		return
+	}
+
+	numero, err := strconv.Atoi(mensagem)
+	muTemporadas.Lock()
+	var arquivada *TemporadaArquivada
+	for i := range temporadasArquivadas {
+		if temporadasArquivadas[i].Temporada.Numero == numero {
+			arquivada = &temporadasArquivadas[i]
+		}
+	}
+	muTemporadas.Unlock()
+
+	if err != nil || arquivada == nil {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: fmt.Sprintf("Temporada %s não foi encerrada ou não existe", mensagem)})
+		return
+	}
+
+	resposta := Resposta{
+		Tipo:      "Ranking_Temporada",
+		Mensagem:  fmt.Sprintf("Ranking final da temporada %d (%s), você não participou", arquivada.Temporada.Numero, arquivada.Temporada.Nome),
+		Ranking:   arquivada.Ranking,
+		Temporada: &arquivada.Temporada,
+	}
+	for _, posicao := range arquivada.Ranking {
+		if posicao.Id == id {
+			resposta.Mensagem = fmt.Sprintf("Ranking final da temporada %d (%s), você terminou em %dº", arquivada.Temporada.Numero, arquivada.Temporada.Nome, posicao.Posicao)
+		}
+	}
+	enviarResposta(conn, resposta)
+}
@@ -0,0 +1,704 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Formatos de torneio
+const (
+	FormatoEliminatoria = "Eliminatoria"
+	FormatoSuico        = "Suico"
+)
+
+// Estados do torneio
+const (
+	TorneioInscricoes = "Inscrições abertas"
+	TorneioAndamento  = "Em andamento"
+	TorneioEncerrado  = "Encerrado"
+)
+
+// Constantes dos torneios
+const (
+	MinInscritos     = 2
+	MaxInscritos     = 16
+	EsperaTorneio    = 30 * time.Second //Tempo para o jogador ficar disponível antes de perder por W.O.
+	IntervaloTorneio = 5 * time.Second  //Pausa entre as rodadas
+)
+
+// Desempenho de um jogador dentro do torneio
+type JogadorTorneio struct {
+	Id        string
+	Pontos    float64 //Vitória vale 1, empate 0.5
+	Vitorias  int
+	Derrotas  int
+	Empates   int
+	Oponentes []string //Oponentes já enfrentados, evita repetição no suíço
+	Folgou    bool     //Já recebeu folga por número ímpar de jogadores
+	Eliminado int      //Rodada em que foi eliminado na eliminatória, 0 se continua vivo
+	Rating    int      //Rating no início do torneio, usado como semente e desempate
+}
+
+// Torneio organizado por um jogador e disputado pelos inscritos
+type Torneio struct {
+	Id          int
+	Organizador string
+	Formato     string
+	Regras      Regras
+	Inscritos   []string //Na ordem de inscrição
+	Jogadores   map[string]*JogadorTorneio
+	Rodada      int
+	Rodadas     int      //Total de rodadas, calculado ao iniciar
+	Chave       []string //Posições da eliminatória, semeadas ao iniciar. Posição vazia é folga
+	Estado      string
+}
+
+// Posição de um jogador na classificação do torneio enviada ao cliente
+type ClassificacaoTorneio struct {
+	Posicao   int     `json:"posicao"`
+	Id        string  `json:"id"`
+	Pontos    float64 `json:"pontos"`
+	Vitorias  int     `json:"vitorias"`
+	Derrotas  int     `json:"derrotas"`
+	Empates   int     `json:"empates"`
+	Desempate float64 `json:"desempate"` //Soma dos pontos dos oponentes no suíço
+}
+
+// Resumo do torneio enviado ao cliente
+type ResumoTorneio struct {
+	Id            int                    `json:"id"`
+	Organizador   string                 `json:"organizador"`
+	Formato       string                 `json:"formato"`
+	Regras        string                 `json:"regras"`
+	Inscritos     []string               `json:"inscritos"`
+	Rodada        int                    `json:"rodada"`
+	Rodadas       int                    `json:"rodadas"`
+	Estado        string                 `json:"estado"`
+	Classificacao []ClassificacaoTorneio `json:"classificacao"`
+}
+
+// Confronto de uma rodada, Jogador2 vazio indica folga
+type Confronto struct {
+	Jogador1 string
+	Jogador2 string
+	Vencedor string //Vazio em caso de empate ou duplo W.O.
+	Motivo   string
+}
+
+// Variáveis dos torneios
+var (
+	torneios       = make(map[int]*Torneio) //Map para guardar os torneios pelo id
+	torneioCounter int                      //Contador do ID dos torneios
+	muTorneios     sync.Mutex               //Mutex para sincronizar os torneios
+)
+
+// Função para buscar o formato pelo nome, sem diferenciar maiúsculas
+func buscarFormato(nome string) (string, bool) {
+	for _, formato := range []string{FormatoEliminatoria, FormatoSuico} {
+		if strings.EqualFold(formato, nome) {
+			return formato, true
+		}
+	}
+	return "", false
+}
+
+// Função para buscar o torneio ativo em que o jogador está inscrito.
+// Deve ser chamada com muTorneios bloqueado
+func torneioDoJogador(id string) *Torneio {
+	for _, t := range torneios {
+		if _, inscrito := t.Jogadores[id]; inscrito && t.Estado != TorneioEncerrado {
+			return t
+		}
+	}
+	return nil
+}
+
+// Função para criar um torneio a partir da mensagem "<formato> [regras]"
+func criarTorneio(conn net.Conn, id, mensagem string) {
+	var resposta Resposta
+
+	campos := strings.Fields(mensagem)
+	nomeFormato := ""
+	if len(campos) > 0 {
+		nomeFormato = campos[0]
+	}
+	formato, ok := buscarFormato(nomeFormato)
+	if !ok {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("O formato do torneio deve ser %s ou %s", FormatoEliminatoria, FormatoSuico)
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	nomeRegras := ""
+	if len(campos) > 1 {
+		nomeRegras = campos[1]
+	}
+	regras, ok := buscarRegras(nomeRegras)
+	if !ok {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Regras de batalha %s não existem", nomeRegras)
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	muTorneios.Lock()
+	torneioCounter++
+	torneio := &Torneio{
+		Id:          torneioCounter,
+		Organizador: id,
+		Formato:     formato,
+		Regras:      regras,
+		Jogadores:   make(map[string]*JogadorTorneio),
+		Estado:      TorneioInscricoes,
+	}
+	torneios[torneio.Id] = torneio
+	resumo := resumirTorneio(torneio)
+	muTorneios.Unlock()
+
+	resposta.Tipo = "Torneio"
+	resposta.Mensagem = fmt.Sprintf("Torneio %d (%s, regras %s) criado! Os jogadores podem se inscrever com o id %d", torneio.Id, formato, regras.Nome, torneio.Id)
+	resposta.Torneios = []ResumoTorneio{resumo}
+	enviarResposta(conn, resposta)
+
+	//Log do servidor
+	color.Cyan("Jogador %s criou o torneio %d (%s)", id, torneio.Id, formato)
+}
+
+// Função para buscar o torneio pelo id na mensagem.
+// Deve ser chamada com muTorneios bloqueado
+func buscarTorneio(mensagem string) (*Torneio, error) {
+	idTorneio, err := strconv.Atoi(strings.TrimSpace(mensagem))
+	torneio, existe := torneios[idTorneio]
+	if err != nil || !existe {
+		return nil, fmt.Errorf("Torneio %s não existe", strings.TrimSpace(mensagem))
+	}
+	return torneio, nil
+}
+
+// Função para inscrever o jogador no torneio com o id da mensagem
+func inscreverTorneio(conn net.Conn, id, mensagem string) {
+	var resposta Resposta
+
+	muTorneios.Lock()
+	torneio, err := buscarTorneio(mensagem)
+	if err == nil && torneio.Estado != TorneioInscricoes {
+		err = fmt.Errorf("As inscrições do torneio %d estão fechadas", torneio.Id)
+	} else if err == nil && torneioDoJogador(id) != nil {
+		err = fmt.Errorf("Você já está inscrito no torneio %d", torneioDoJogador(id).Id)
+	} else if err == nil && len(torneio.Inscritos) >= MaxInscritos {
+		err = fmt.Errorf("O torneio %d já tem o máximo de %d inscritos", torneio.Id, MaxInscritos)
+	}
+	if err != nil {
+		muTorneios.Unlock()
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	torneio.Inscritos = append(torneio.Inscritos, id)
+	torneio.Jogadores[id] = &JogadorTorneio{Id: id}
+	resumo := resumirTorneio(torneio)
+	avisar := append([]string{torneio.Organizador}, torneio.Inscritos...)
+	muTorneios.Unlock()
+
+	resposta.Tipo = "Torneio"
+	resposta.Mensagem = fmt.Sprintf("Jogador %s se inscreveu no torneio %d (%d inscritos)", id, torneio.Id, len(resumo.Inscritos))
+	resposta.Torneios = []ResumoTorneio{resumo}
+	enviarParaJogadores(resposta, semRepetidos(avisar)...)
+
+	//Log do servidor
+	color.Cyan("Jogador %s se inscreveu no torneio %d", id, torneio.Id)
+}
+
+// Função para o organizador fechar as inscrições e iniciar o torneio
+func iniciarTorneio(conn net.Conn, id, mensagem string) {
+	var resposta Resposta
+
+	muTorneios.Lock()
+	torneio, err := buscarTorneio(mensagem)
+	if err == nil && torneio.Organizador != id {
+		err = fmt.Errorf("Apenas o organizador pode iniciar o torneio %d", torneio.Id)
+	} else if err == nil && torneio.Estado != TorneioInscricoes {
+		err = fmt.Errorf("O torneio %d já foi iniciado", torneio.Id)
+	} else if err == nil && len(torneio.Inscritos) < MinInscritos {
+		err = fmt.Errorf("O torneio precisa de pelo menos %d inscritos", MinInscritos)
+	}
+	if err != nil {
+		muTorneios.Unlock()
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = err.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	//Rating atual como semente da chave e desempate, mesma ordem de bloqueio do ranking
+	muRatings.Lock()
+	for _, jogador := range torneio.Jogadores {
+		jogador.Rating = int(math.Round(buscarRating(jogador.Id).Pontos))
+	}
+	muRatings.Unlock()
+
+	torneio.Estado = TorneioAndamento
+	torneio.Rodadas = int(math.Ceil(math.Log2(float64(len(torneio.Inscritos)))))
+	if torneio.Formato == FormatoEliminatoria {
+		torneio.Chave = semearChave(torneio)
+	}
+	muTorneios.Unlock()
+
+	go realizarTorneio(torneio)
+}
+
+// Função para realizar as rodadas do torneio até sobrar um campeão ou acabarem as rodadas do suíço
+func realizarTorneio(torneio *Torneio) {
+	color.Yellow("Iniciando torneio %d (%s) com %d jogadores", torneio.Id, torneio.Formato, len(torneio.Inscritos))
+
+	for {
+		muTorneios.Lock()
+		torneio.Rodada++
+		rodada := torneio.Rodada
+		var confrontos []*Confronto
+		if torneio.Formato == FormatoEliminatoria {
+			confrontos = emparelharEliminatoria(torneio)
+		} else {
+			confrontos = emparelharSuico(torneio)
+		}
+		muTorneios.Unlock()
+
+		anunciarRodada(torneio, rodada, confrontos)
+
+		//Batalhas da rodada acontecem ao mesmo tempo
+		var wg sync.WaitGroup
+		for _, confronto := range confrontos {
+			if confronto.Jogador2 == "" {
+				confronto.Vencedor = confronto.Jogador1
+				confronto.Motivo = "Folga"
+				continue
+			}
+			wg.Add(1)
+			go func(c *Confronto) {
+				defer wg.Done()
+				disputarConfronto(torneio, c)
+			}(confronto)
+		}
+		wg.Wait()
+
+		muTorneios.Lock()
+		for _, confronto := range confrontos {
+			registrarConfronto(torneio, rodada, confronto)
+		}
+		if torneio.Formato == FormatoEliminatoria {
+			avancarChave(torneio)
+		}
+		terminou := rodada >= torneio.Rodadas
+		if torneio.Formato == FormatoEliminatoria {
+			terminou = len(vivosEliminatoria(torneio)) <= 1
+		}
+		resumo := resumirTorneio(torneio)
+		inscritos := append([]string(nil), torneio.Inscritos...)
+		muTorneios.Unlock()
+
+		if terminou {
+			break
+		}
+
+		resposta := Resposta{Tipo: "Torneio", Mensagem: fmt.Sprintf("Rodada %d do torneio %d encerrada", rodada, torneio.Id), Torneios: []ResumoTorneio{resumo}}
+		enviarParaJogadores(resposta, inscritos...)
+		time.Sleep(IntervaloTorneio)
+	}
+
+	encerrarTorneio(torneio)
+}
+
+// Função para semear a chave da eliminatória pelo rating inicial, uma única vez ao iniciar o torneio.
+// A chave tem o tamanho da próxima potência de 2, a melhor semente enfrenta a pior e as folgas ficam com as melhores.
+// Deve ser chamada com muTorneios bloqueado
+func semearChave(torneio *Torneio) []string {
+	sementes := make([]*JogadorTorneio, 0, len(torneio.Inscritos))
+	for _, id := range torneio.Inscritos {
+		sementes = append(sementes, torneio.Jogadores[id])
+	}
+	sort.SliceStable(sementes, func(i, j int) bool {
+		return sementes[i].Rating > sementes[j].Rating
+	})
+
+	//Ordem das sementes nas posições, ex: com 8 posições 1 x 8, 4 x 5, 2 x 7, 3 x 6
+	ordem := []int{1}
+	for len(ordem) < len(sementes) {
+		proxima := make([]int, 0, len(ordem)*2)
+		for _, semente := range ordem {
+			proxima = append(proxima, semente, len(ordem)*2+1-semente)
+		}
+		ordem = proxima
+	}
+
+	chave := make([]string, len(ordem))
+	for i, semente := range ordem {
+		if semente <= len(sementes) {
+			chave[i] = sementes[semente-1].Id
+		}
+	}
+	return chave
+}
+
+// Função para emparelhar as posições vizinhas da chave da eliminatória.
+// Quem fica sem oponente folga. Deve ser chamada com muTorneios bloqueado
+func emparelharEliminatoria(torneio *Torneio) []*Confronto {
+	confrontos := make([]*Confronto, 0, len(torneio.Chave)/2)
+	for i := 0; i+1 < len(torneio.Chave); i += 2 {
+		jogador1, jogador2 := torneio.Chave[i], torneio.Chave[i+1]
+		if jogador1 == "" {
+			jogador1, jogador2 = jogador2, ""
+		}
+		if jogador1 == "" {
+			continue //Duplo W.O. na rodada anterior
+		}
+		confrontos = append(confrontos, &Confronto{Jogador1: jogador1, Jogador2: jogador2})
+	}
+	return confrontos
+}
+
+// Função para avançar na chave quem continua vivo em cada confronto, mantendo as posições da semeadura.
+// Deve ser chamada com muTorneios bloqueado
+func avancarChave(torneio *Torneio) {
+	proxima := make([]string, 0, len(torneio.Chave)/2)
+	for i := 0; i+1 < len(torneio.Chave); i += 2 {
+		vivo := ""
+		for _, id := range torneio.Chave[i : i+2] {
+			if id != "" && torneio.Jogadores[id].Eliminado == 0 {
+				vivo = id
+			}
+		}
+		proxima = append(proxima, vivo)
+	}
+	torneio.Chave = proxima
+}
+
+// Função para listar os jogadores ainda vivos na eliminatória, na ordem de inscrição.
+// Deve ser chamada com muTorneios bloqueado
+func vivosEliminatoria(torneio *Torneio) []*JogadorTorneio {
+	vivos := make([]*JogadorTorneio, 0, len(torneio.Inscritos))
+	for _, id := range torneio.Inscritos {
+		if torneio.Jogadores[id].Eliminado == 0 {
+			vivos = append(vivos, torneio.Jogadores[id])
+		}
+	}
+	return vivos
+}
+
+// Função para emparelhar o suíço por pontos, evitando repetir oponentes quando possível.
+// Com número ímpar o pior colocado que ainda não folgou recebe a folga. Deve ser chamada com muTorneios bloqueado
+func emparelharSuico(torneio *Torneio) []*Confronto {
+	ordem := classificarSuico(torneio)
+	confrontos := make([]*Confronto, 0, len(ordem)/2+1)
+
+	if len(ordem)%2 == 1 {
+		folga := len(ordem) - 1
+		for i := len(ordem) - 1; i >= 0; i-- {
+			if !ordem[i].Folgou {
+				folga = i
+				break
+			}
+		}
+		confrontos = append(confrontos, &Confronto{Jogador1: ordem[folga].Id})
+		ordem = append(ordem[:folga:folga], ordem[folga+1:]...)
+	}
+
+	emparelhado := make([]bool, len(ordem))
+	for i := range ordem {
+		if emparelhado[i] {
+			continue
+		}
+		//Primeiro livre abaixo que ainda não enfrentou, ou o primeiro livre se todos já se enfrentaram
+		escolhido := -1
+		for j := i + 1; j < len(ordem); j++ {
+			if emparelhado[j] {
+				continue
+			}
+			if escolhido == -1 {
+				escolhido = j
+			}
+			if !contem(ordem[i].Oponentes, ordem[j].Id) {
+				escolhido = j
+				break
+			}
+		}
+		emparelhado[i], emparelhado[escolhido] = true, true
+		confrontos = append(confrontos, &Confronto{Jogador1: ordem[i].Id, Jogador2: ordem[escolhido].Id})
+	}
+	return confrontos
+}
+
+// Função para ordenar os jogadores do suíço por pontos, desempate e rating inicial.
+// Deve ser chamada com muTorneios bloqueado
+func classificarSuico(torneio *Torneio) []*JogadorTorneio {
+	ordem := make([]*JogadorTorneio, 0, len(torneio.Inscritos))
+	for _, id := range torneio.Inscritos {
+		ordem = append(ordem, torneio.Jogadores[id])
+	}
+	sort.SliceStable(ordem, func(i, j int) bool {
+		if ordem[i].Pontos != ordem[j].Pontos {
+			return ordem[i].Pontos > ordem[j].Pontos
+		}
+		if di, dj := desempateSuico(torneio, ordem[i]), desempateSuico(torneio, ordem[j]); di != dj {
+			return di > dj
+		}
+		return ordem[i].Rating > ordem[j].Rating
+	})
+	return ordem
+}
+
+// Função para calcular o desempate do suíço, a soma dos pontos dos oponentes enfrentados.
+// Deve ser chamada com muTorneios bloqueado
+func desempateSuico(torneio *Torneio, jogador *JogadorTorneio) float64 {
+	soma := 0.0
+	for _, oponente := range jogador.Oponentes {
+		soma += torneio.Jogadores[oponente].Pontos
+	}
+	return soma
+}
+
+// Função para avisar cada inscrito do seu confronto na rodada
+func anunciarRodada(torneio *Torneio, rodada int, confrontos []*Confronto) {
+	for _, confronto := range confrontos {
+		mensagem := fmt.Sprintf("Torneio %d, rodada %d de %d: ", torneio.Id, rodada, torneio.Rodadas)
+		if confronto.Jogador2 == "" {
+			enviarParaJogadores(Resposta{Tipo: "Torneio", Mensagem: mensagem + "você folga nesta rodada e conta como vitória"}, confronto.Jogador1)
+			continue
+		}
+		enviarParaJogadores(Resposta{Tipo: "Torneio", Mensagem: mensagem + "você enfrenta o jogador " + confronto.Jogador2}, confronto.Jogador1)
+		enviarParaJogadores(Resposta{Tipo: "Torneio", Mensagem: mensagem + "você enfrenta o jogador " + confronto.Jogador1}, confronto.Jogador2)
+	}
+
+	//Log do servidor
+	color.Yellow("Torneio %d: rodada %d com %d confrontos", torneio.Id, rodada, len(confrontos))
+}
+
+// Função para verificar se o jogador está conectado, livre para batalhar e com cartas para o deck
+func disponivelTorneio(id string, tamanho int) bool {
+	return verificarConectado(id) == nil && !emBatalha(id) && deckPronto(id, tamanho)
+}
+
+// Função para verificar se o jogador está conectado e não está ausente esperando reconexão
+func verificarConectado(id string) error {
+	muClientes.RLock()
+	_, conectado := clientes[id]
+	muClientes.RUnlock()
+	if !conectado || estaAusente(id) {
+		return fmt.Errorf("Jogador %s não está conectado", id)
+	}
+	return nil
+}
+
+// Função para disputar um confronto. Quem não fica disponível dentro do tempo de espera perde por W.O.
+func disputarConfronto(torneio *Torneio, confronto *Confronto) {
+	limite := time.Now().Add(EsperaTorneio)
+	tamanho := torneio.Regras.TamanhoDeck
+	var batalha *Batalha
+	for {
+		//Conexão conferida no mesmo bloqueio que inicia a batalha, quem cai antes do início não entra nela
+		var err error
+		if batalha, err = criarBatalha(ModoDuelo, []string{confronto.Jogador1, confronto.Jogador2}, torneio.Regras, nil, nil, verificarConectado); err == nil {
+			break
+		}
+		if time.Now().After(limite) {
+			confronto.Motivo = "W.O."
+			if disponivelTorneio(confronto.Jogador1, tamanho) {
+				confronto.Vencedor = confronto.Jogador1
+			} else if disponivelTorneio(confronto.Jogador2, tamanho) {
+				confronto.Vencedor = confronto.Jogador2
+			}
+			mensagem := fmt.Sprintf("Torneio %d: confronto entre %s e %s decidido por W.O., vencedor %s", torneio.Id, confronto.Jogador1, confronto.Jogador2, nomeVencedor(semRepetidos([]string{confronto.Vencedor})))
+			enviarParaJogadores(Resposta{Tipo: "Torneio", Mensagem: mensagem}, confronto.Jogador1, confronto.Jogador2)
+			return
+		}
+		time.Sleep(IntervaloVerificacao)
+	}
+
+	realizarBatalha(batalha)
+
+	confronto.Motivo = batalha.Motivo
+	if batalha.venceu(confronto.Jogador1) {
+		confronto.Vencedor = confronto.Jogador1
+	} else if batalha.venceu(confronto.Jogador2) {
+		confronto.Vencedor = confronto.Jogador2
+	} else if torneio.Formato == FormatoEliminatoria {
+		//Eliminatória não aceita empate, avança quem causou mais dano e depois a melhor semente
+		confronto.Motivo = "Desempate por dano"
+		dano1, dano2 := batalha.participante(confronto.Jogador1).Dano, batalha.participante(confronto.Jogador2).Dano
+		if dano2 > dano1 {
+			confronto.Vencedor = confronto.Jogador2
+		} else {
+			confronto.Vencedor = confronto.Jogador1
+		}
+	}
+}
+
+// Função para registrar o resultado do confronto na pontuação dos jogadores.
+// Deve ser chamada com muTorneios bloqueado
+func registrarConfronto(torneio *Torneio, rodada int, confronto *Confronto) {
+	jogador1 := torneio.Jogadores[confronto.Jogador1]
+	if confronto.Jogador2 == "" {
+		jogador1.Folgou = true
+		jogador1.Pontos++
+		return
+	}
+	jogador2 := torneio.Jogadores[confronto.Jogador2]
+	jogador1.Oponentes = append(jogador1.Oponentes, jogador2.Id)
+	jogador2.Oponentes = append(jogador2.Oponentes, jogador1.Id)
+
+	switch confronto.Vencedor {
+	case jogador1.Id:
+		jogador1.Pontos++
+		jogador1.Vitorias++
+		jogador2.Derrotas++
+	case jogador2.Id:
+		jogador2.Pontos++
+		jogador2.Vitorias++
+		jogador1.Derrotas++
+	default:
+		//Empate no suíço, ou duplo W.O. que elimina os dois
+		if confronto.Motivo == "W.O." {
+			jogador1.Derrotas++
+			jogador2.Derrotas++
+		} else {
+			jogador1.Pontos += 0.5
+			jogador2.Pontos += 0.5
+			jogador1.Empates++
+			jogador2.Empates++
+		}
+	}
+
+	//Na eliminatória quem não venceu o confronto cai
+	if torneio.Formato == FormatoEliminatoria {
+		for _, jogador := range []*JogadorTorneio{jogador1, jogador2} {
+			if jogador.Id != confronto.Vencedor {
+				jogador.Eliminado = rodada
+			}
+		}
+	}
+
+	//Log do servidor
+	color.Yellow("Torneio %d, rodada %d: %s x %s, vencedor %s (%s)", torneio.Id, rodada, jogador1.Id, jogador2.Id, nomeVencedor(semRepetidos([]string{confronto.Vencedor})), confronto.Motivo)
+}
+
+// Função para montar a classificação do torneio.
+// Na eliminatória quem caiu mais tarde fica à frente, quem caiu na mesma rodada divide a posição.
+// Deve ser chamada com muTorneios bloqueado
+func classificarTorneio(torneio *Torneio) []ClassificacaoTorneio {
+	var ordem []*JogadorTorneio
+	if torneio.Formato == FormatoSuico {
+		ordem = classificarSuico(torneio)
+	} else {
+		ordem = make([]*JogadorTorneio, 0, len(torneio.Inscritos))
+		for _, id := range torneio.Inscritos {
+			ordem = append(ordem, torneio.Jogadores[id])
+		}
+		sort.SliceStable(ordem, func(i, j int) bool {
+			return rodadaQueda(ordem[i]) > rodadaQueda(ordem[j])
+		})
+	}
+
+	classificacao := make([]ClassificacaoTorneio, 0, len(ordem))
+	for i, jogador := range ordem {
+		posicao := i + 1
+		if torneio.Formato == FormatoEliminatoria && i > 0 && rodadaQueda(jogador) == rodadaQueda(ordem[i-1]) {
+			posicao = classificacao[i-1].Posicao
+		}
+		classificacao = append(classificacao, ClassificacaoTorneio{
+			Posicao:   posicao,
+			Id:        jogador.Id,
+			Pontos:    jogador.Pontos,
+			Vitorias:  jogador.Vitorias,
+			Derrotas:  jogador.Derrotas,
+			Empates:   jogador.Empates,
+			Desempate: desempateSuico(torneio, jogador),
+		})
+	}
+	return classificacao
+}
+
+// Função para ordenar a queda na eliminatória, quem não caiu fica acima de todos
+func rodadaQueda(jogador *JogadorTorneio) int {
+	if jogador.Eliminado == 0 {
+		return math.MaxInt
+	}
+	return jogador.Eliminado
+}
+
+// Função para resumir o torneio para o cliente. Deve ser chamada com muTorneios bloqueado
+func resumirTorneio(torneio *Torneio) ResumoTorneio {
+	resumo := ResumoTorneio{
+		Id:          torneio.Id,
+		Organizador: torneio.Organizador,
+		Formato:     torneio.Formato,
+		Regras:      torneio.Regras.Nome,
+		Inscritos:   append([]string{}, torneio.Inscritos...),
+		Rodada:      torneio.Rodada,
+		Rodadas:     torneio.Rodadas,
+		Estado:      torneio.Estado,
+	}
+	if torneio.Estado != TorneioInscricoes {
+		resumo.Classificacao = classificarTorneio(torneio)
+	}
+	return resumo
+}
+
+// Função para encerrar o torneio e enviar a classificação final para os participantes e o organizador
+func encerrarTorneio(torneio *Torneio) {
+	muTorneios.Lock()
+	torneio.Estado = TorneioEncerrado
+	resumo := resumirTorneio(torneio)
+	avisar := semRepetidos(append([]string{torneio.Organizador}, torneio.Inscritos...))
+	muTorneios.Unlock()
+
+	campeao := resumo.Classificacao[0].Id
+	resposta := Resposta{
+		Tipo:     "Fim_Torneio",
+		Mensagem: fmt.Sprintf("Torneio %d (%s) encerrado! Campeão: jogador %s", torneio.Id, torneio.Formato, campeao),
+		Torneios: []ResumoTorneio{resumo},
+	}
+	enviarParaJogadores(resposta, avisar...)
+
+	//Log do servidor
+	color.Yellow("Torneio %d encerrado, campeão %s", torneio.Id, campeao)
+}
+
+// Função para enviar a lista de torneios que não foram encerrados
+func listarTorneios(conn net.Conn) {
+	muTorneios.Lock()
+	lista := make([]ResumoTorneio, 0, len(torneios))
+	for _, t := range torneios {
+		if t.Estado != TorneioEncerrado {
+			lista = append(lista, resumirTorneio(t))
+		}
+	}
+	muTorneios.Unlock()
+
+	sort.Slice(lista, func(i, j int) bool {
+		return lista[i].Id < lista[j].Id
+	})
+	enviarResposta(conn, Resposta{Tipo: "Lista_Torneios", Torneios: lista})
+}
+
+// Função para remover ids repetidos e vazios mantendo a ordem
+func semRepetidos(ids []string) []string {
+	vistos := make(map[string]bool)
+	resultado := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != "" && !vistos[id] {
+			vistos[id] = true
+			resultado = append(resultado, id)
+		}
+	}
+	return resultado
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestInscricoesSimultaneas(t *testing.T) {
+	torneios = map[int]*Torneio{
+		1: {Id: 1, Organizador: "o", Jogadores: make(map[string]*JogadorTorneio), Estado: TorneioInscricoes},
+		2: {Id: 2, Organizador: "o", Jogadores: make(map[string]*JogadorTorneio), Estado: TorneioInscricoes},
+	}
+
+	//Cada jogador tenta entrar nos dois torneios ao mesmo tempo, com mais jogadores do que vagas
+	jogadores := MaxInscritos*2 + 4
+	var wg sync.WaitGroup
+	for i := 0; i < jogadores; i++ {
+		for _, idTorneio := range []string{"1", "2"} {
+			wg.Add(1)
+			go func(id, idTorneio string) {
+				defer wg.Done()
+				servidor, cliente := net.Pipe()
+				go io.Copy(io.Discard, cliente)
+				inscreverTorneio(servidor, id, idTorneio)
+				servidor.Close()
+			}(strconv.Itoa(i), idTorneio)
+		}
+	}
+	wg.Wait()
+
+	inscritos := make(map[string]int)
+	for _, torneio := range torneios {
+		if len(torneio.Inscritos) != MaxInscritos {
+			t.Errorf("torneio %d com %d inscritos, esperado %d", torneio.Id, len(torneio.Inscritos), MaxInscritos)
+		}
+		if len(torneio.Jogadores) != len(torneio.Inscritos) {
+			t.Errorf("torneio %d com %d jogadores para %d inscrições", torneio.Id, len(torneio.Jogadores), len(torneio.Inscritos))
+		}
+		for _, id := range torneio.Inscritos {
+			inscritos[id]++
+		}
+	}
+	for id, vezes := range inscritos {
+		if vezes > 1 {
+			t.Errorf("jogador %s inscrito %d vezes", id, vezes)
+		}
+	}
+}
+
+func TestChaveEliminatoria(t *testing.T) {
+	//Cinco jogadores, a vence todos e o melhor rating vence cada confronto
+	ratings := map[string]int{"a": 1500, "b": 1400, "c": 1300, "d": 1200, "e": 1100}
+	torneio := &Torneio{Id: 1, Formato: FormatoEliminatoria, Inscritos: []string{"e", "d", "c", "b", "a"}, Jogadores: make(map[string]*JogadorTorneio)}
+	for id, rating := range ratings {
+		torneio.Jogadores[id] = &JogadorTorneio{Id: id, Rating: rating}
+	}
+	torneio.Chave = semearChave(torneio)
+
+	//As melhores sementes folgam e só se encontram nas rodadas finais
+	rodadas := [][]string{
+		{"a x ", "d x e", "b x ", "c x "},
+		{"a x d", "b x c"},
+		{"a x b"},
+	}
+	for rodada, esperados := range rodadas {
+		confrontos := emparelharEliminatoria(torneio)
+		if len(confrontos) != len(esperados) {
+			t.Fatalf("rodada %d com %d confrontos, esperado %d", rodada+1, len(confrontos), len(esperados))
+		}
+		for i, confronto := range confrontos {
+			if obtido := confronto.Jogador1 + " x " + confronto.Jogador2; obtido != esperados[i] {
+				t.Errorf("rodada %d, confronto %d: %q, esperado %q", rodada+1, i+1, obtido, esperados[i])
+			}
+			confronto.Vencedor = confronto.Jogador1
+			if confronto.Jogador2 != "" && ratings[confronto.Jogador2] > ratings[confronto.Jogador1] {
+				confronto.Vencedor = confronto.Jogador2
+			}
+			registrarConfronto(torneio, rodada+1, confronto)
+		}
+		avancarChave(torneio)
+	}
+
+	if vivos := vivosEliminatoria(torneio); len(vivos) != 1 || vivos[0].Id != "a" {
+		t.Errorf("campeão %v, esperado a", vivos)
+	}
+	if torneio.Jogadores["b"].Eliminado != 3 || torneio.Jogadores["e"].Eliminado != 1 {
+		t.Errorf("b eliminado na rodada %d e e na %d, esperado 3 e 1", torneio.Jogadores["b"].Eliminado, torneio.Jogadores["e"].Eliminado)
+	}
+}
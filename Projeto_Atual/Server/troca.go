@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// Proposta de troca de cartas entre 2 jogadores do mesmo grupo
+type Troca struct {
+	Id           int      `json:"id"`
+	Proponente   string   `json:"proponente"`
+	Destinatario string   `json:"destinatario"`
+	Oferecidas   []Tanque `json:"oferecidas"` //Cartas do proponente
+	Pedidas      []Tanque `json:"pedidas"`    //Cartas do destinatário
+}
+
+// Variáveis das trocas
+var (
+	trocas       = make(map[int]*Troca) //Trocas pendentes pelo id
+	trocaCounter int                    //Contador para gerar ids das trocas
+	muTrocas     sync.Mutex             //Mutex para sincronizar as trocas, bloqueado antes de muColecoes
+)
+
+// Função para enviar a coleção de um jogador do grupo, usada para escolher as cartas pedidas
+func verColecao(conn net.Conn, id, idAlvo string) {
+	if idAlvo == "" || idAlvo == "None" {
+		idAlvo = id
+	}
+	if idAlvo != id && !mesmoGrupo(id, idAlvo) {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: "Você só pode ver a coleção de jogadores do seu grupo"})
+		return
+	}
+
+	muColecoes.RLock()
+	cartas := append([]Tanque(nil), colecoes[idAlvo]...)
+	muColecoes.RUnlock()
+
+	enviarResposta(conn, Resposta{Tipo: "Colecao", Mensagem: idAlvo, Cartas: cartas})
+}
+
+// Função para criar uma proposta de troca, substituindo a troca anterior no caso de contraproposta
+func proporTroca(conn net.Conn, id, idDestinatario string, oferecidas, pedidas []Tanque, substituida int) {
+	var resposta Resposta
+
+	if id == idDestinatario || !mesmoGrupo(id, idDestinatario) {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "Você só pode trocar cartas com um jogador do seu grupo"
+		enviarResposta(conn, resposta)
+		return
+	}
+	if err := verificarBloqueio(id, idDestinatario); err != nil {
+		enviarErroModeracao(conn, err)
+		return
+	}
+	if len(oferecidas) == 0 && len(pedidas) == 0 {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = "A troca precisa de pelo menos uma carta"
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	muTrocas.Lock()
+	defer muTrocas.Unlock()
+
+	//Cartas precisam existir nas coleções no momento da proposta, e são conferidas de novo ao aceitar
+	muColecoes.RLock()
+	_, errOferecidas := encontrarNaColecao(colecoes[id], oferecidas)
+	_, errPedidas := encontrarNaColecao(colecoes[idDestinatario], pedidas)
+	muColecoes.RUnlock()
+	if errOferecidas != nil {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = errOferecidas.Error()
+		enviarResposta(conn, resposta)
+		return
+	}
+	if errPedidas != nil {
+		resposta.Tipo = "Erro"
+		resposta.Mensagem = fmt.Sprintf("Jogador %s não tem as cartas pedidas", idDestinatario)
+		enviarResposta(conn, resposta)
+		return
+	}
+
+	delete(trocas, substituida)
+	trocaCounter++
+	troca := &Troca{
+		Id:           trocaCounter,
+		Proponente:   id,
+		Destinatario: idDestinatario,
+		Oferecidas:   oferecidas,
+		Pedidas:      pedidas,
+	}
+	trocas[troca.Id] = troca
+
+	resposta.Tipo = "Troca_Proposta"
+	resposta.Mensagem = fmt.Sprintf("Jogador %s propôs a troca %d para o jogador %s", id, troca.Id, idDestinatario)
+	if substituida != 0 {
+		resposta.Mensagem = fmt.Sprintf("Jogador %s fez a contraproposta %d no lugar da troca %d", id, troca.Id, substituida)
+	}
+	resposta.Trocas = []Troca{*troca}
+	enviarParaJogadores(resposta, id, idDestinatario)
+
+	//Log do servidor
+	color.Cyan("Troca %d proposta por %s para %s", troca.Id, id, idDestinatario)
+}
+
+// Função para o destinatário responder a troca com uma contraproposta, trocando os papéis
+func contraProporTroca(conn net.Conn, id, mensagem string, oferecidas, pedidas []Tanque) {
+	muTrocas.Lock()
+	troca, existe := buscarTroca(mensagem)
+	muTrocas.Unlock()
+
+	if !existe || troca.Destinatario != id {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: "Troca não encontrada entre as propostas recebidas"})
+		return
+	}
+	proporTroca(conn, id, troca.Proponente, oferecidas, pedidas, troca.Id)
+}
+
+// Função para o destinatário aceitar a troca, trocando as cartas de dono de uma só vez
+func aceitarTroca(conn net.Conn, id, mensagem string) {
+	muTrocas.Lock()
+	defer muTrocas.Unlock()
+
+	troca, existe := buscarTroca(mensagem)
+	if !existe || troca.Destinatario != id {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: "Troca não encontrada entre as propostas recebidas"})
+		return
+	}
+	delete(trocas, troca.Id)
+
+	if !mesmoGrupo(troca.Proponente, troca.Destinatario) {
+		avisarCancelamento(troca, "os jogadores não estão mais no mesmo grupo")
+		return
+	}
+
+	//Conferência e troca com as coleções bloqueadas, nenhuma carta é duplicada ou perdida
+	muColecoes.Lock()
+	posicoesOferecidas, errOferecidas := encontrarNaColecao(colecoes[troca.Proponente], troca.Oferecidas)
+	posicoesPedidas, errPedidas := encontrarNaColecao(colecoes[troca.Destinatario], troca.Pedidas)
+	if errOferecidas != nil || errPedidas != nil {
+		muColecoes.Unlock()
+		avisarCancelamento(troca, "as cartas não estão mais disponíveis")
+		return
+	}
+
+	recebidasProponente := transferirCartas(troca.Destinatario, posicoesPedidas, troca.Proponente)
+	recebidasDestinatario := transferirCartas(troca.Proponente, posicoesOferecidas, troca.Destinatario)
+	colecoes[troca.Proponente] = append(colecoes[troca.Proponente], recebidasProponente...)
+	colecoes[troca.Destinatario] = append(colecoes[troca.Destinatario], recebidasDestinatario...)
+	registrarPosse(recebidasProponente, troca.Proponente, fmt.Sprintf("Troca %d", troca.Id))
+	registrarPosse(recebidasDestinatario, troca.Destinatario, fmt.Sprintf("Troca %d", troca.Id))
+
+	colecaoProponente := append([]Tanque(nil), colecoes[troca.Proponente]...)
+	colecaoDestinatario := append([]Tanque(nil), colecoes[troca.Destinatario]...)
+	muColecoes.Unlock()
+
+	//Cada jogador recebe a sua coleção atualizada
+	mensagem = fmt.Sprintf("Troca %d concluída entre %s e %s", troca.Id, troca.Proponente, troca.Destinatario)
+	enviarParaJogadores(Resposta{Tipo: "Troca_Concluida", Mensagem: mensagem, Cartas: colecaoProponente}, troca.Proponente)
+	enviarParaJogadores(Resposta{Tipo: "Troca_Concluida", Mensagem: mensagem, Cartas: colecaoDestinatario}, troca.Destinatario)
+	registrarEventoMissao(troca.Proponente, "Negocio", 1)
+	registrarEventoMissao(troca.Destinatario, "Negocio", 1)
+
+	//Log do servidor
+	color.Green(mensagem)
+}
+
+// Função para retirar as cartas das posições informadas da coleção do dono e passá-las para o novo dono.
+// Deve ser chamada com muColecoes bloqueado
+func transferirCartas(dono string, posicoes []int, novoDono string) []Tanque {
+	retirar := make(map[int]bool, len(posicoes))
+	for _, i := range posicoes {
+		retirar[i] = true
+	}
+
+	restantes := make([]Tanque, 0, len(colecoes[dono]))
+	transferidas := make([]Tanque, 0, len(posicoes))
+	for i, carta := range colecoes[dono] {
+		if retirar[i] {
+			carta.Id_jogador = novoDono
+			transferidas = append(transferidas, carta)
+		} else {
+			restantes = append(restantes, carta)
+		}
+	}
+	colecoes[dono] = restantes
+	return transferidas
+}
+
+// Função para qualquer um dos lados cancelar ou recusar a troca
+func cancelarTroca(conn net.Conn, id, mensagem string) {
+	muTrocas.Lock()
+	defer muTrocas.Unlock()
+
+	troca, existe := buscarTroca(mensagem)
+	if !existe || (troca.Proponente != id && troca.Destinatario != id) {
+		enviarResposta(conn, Resposta{Tipo: "Erro", Mensagem: "Troca não encontrada"})
+		return
+	}
+	delete(trocas, troca.Id)
+	avisarCancelamento(troca, fmt.Sprintf("cancelada pelo jogador %s", id))
+}
+
+// Função para cancelar todas as trocas pendentes do jogador, usada quando ele sai do grupo ou desconecta
+func cancelarTrocasDe(id string) {
+	muTrocas.Lock()
+	defer muTrocas.Unlock()
+
+	for idTroca, troca := range trocas {
+		if troca.Proponente == id || troca.Destinatario == id {
+			delete(trocas, idTroca)
+			avisarCancelamento(troca, fmt.Sprintf("o jogador %s saiu", id))
+		}
+	}
+}
+
+// Função para listar as trocas pendentes em que o jogador participa
+func listarTrocas(conn net.Conn, id string) {
+	muTrocas.Lock()
+	lista := make([]Troca, 0)
+	for _, troca := range trocas {
+		if troca.Proponente == id || troca.Destinatario == id {
+			lista = append(lista, *troca)
+		}
+	}
+	muTrocas.Unlock()
+
+	sort.Slice(lista, func(i, j int) bool { return lista[i].Id < lista[j].Id })
+	enviarResposta(conn, Resposta{Tipo: "Lista_Trocas", Trocas: lista})
+}
+
+// Função para buscar a troca pelo id em texto. Deve ser chamada com muTrocas bloqueado
+func buscarTroca(mensagem string) (*Troca, bool) {
+	idTroca, err := strconv.Atoi(strings.TrimSpace(mensagem))
+	if err != nil {
+		return nil, false
+	}
+	troca, existe := trocas[idTroca]
+	return troca, existe
+}
+
+// Função para avisar os 2 lados que a troca foi cancelada
+func avisarCancelamento(troca *Troca, motivo string) {
+	resposta := Resposta{Tipo: "Troca_Cancelada", Mensagem: fmt.Sprintf("Troca %d cancelada: %s", troca.Id, motivo)}
+	enviarParaJogadores(resposta, troca.Proponente, troca.Destinatario)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// Função para criar uma carta de teste com o dono e o id de instância
+func cartaTeste(modelo, dono string, instancia int) Tanque {
+	return Tanque{Modelo: modelo, Id_jogador: dono, Vida: 10, Ataque: 5, Instancia: instancia}
+}
+
+// Função para executar a ação com uma conexão em memória e devolver as respostas enviadas nela
+func respostasDe(acao func(conn net.Conn)) []Resposta {
+	servidor, cliente := net.Pipe()
+	lidas := make(chan []Resposta)
+	go func() {
+		var respostas []Resposta
+		decoder := json.NewDecoder(cliente)
+		for {
+			var resposta Resposta
+			if err := decoder.Decode(&resposta); err != nil {
+				lidas <- respostas
+				return
+			}
+			respostas = append(respostas, resposta)
+		}
+	}()
+	acao(servidor)
+	servidor.Close()
+	return <-lidas
+}
+
+func TestTransferirCartas(t *testing.T) {
+	casos := []struct {
+		nome         string
+		colecao      []Tanque
+		posicoes     []int
+		restantes    []Tanque
+		transferidas []Tanque
+	}{
+		{
+			nome:         "uma carta do meio",
+			colecao:      []Tanque{cartaTeste("A", "1", 1), cartaTeste("B", "1", 2), cartaTeste("C", "1", 3)},
+			posicoes:     []int{1},
+			restantes:    []Tanque{cartaTeste("A", "1", 1), cartaTeste("C", "1", 3)},
+			transferidas: []Tanque{cartaTeste("B", "2", 2)},
+		},
+		{
+			nome:         "posições fora de ordem mantêm a ordem da coleção",
+			colecao:      []Tanque{cartaTeste("A", "1", 1), cartaTeste("B", "1", 2), cartaTeste("C", "1", 3)},
+			posicoes:     []int{2, 0},
+			restantes:    []Tanque{cartaTeste("B", "1", 2)},
+			transferidas: []Tanque{cartaTeste("A", "2", 1), cartaTeste("C", "2", 3)},
+		},
+		{
+			nome:         "coleção inteira",
+			colecao:      []Tanque{cartaTeste("A", "1", 1), cartaTeste("A", "1", 2)},
+			posicoes:     []int{0, 1},
+			restantes:    []Tanque{},
+			transferidas: []Tanque{cartaTeste("A", "2", 1), cartaTeste("A", "2", 2)},
+		},
+		{
+			nome:         "nenhuma posição",
+			colecao:      []Tanque{cartaTeste("A", "1", 1)},
+			posicoes:     nil,
+			restantes:    []Tanque{cartaTeste("A", "1", 1)},
+			transferidas: []Tanque{},
+		},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			colecoes = map[string][]Tanque{"1": c.colecao}
+
+			transferidas := transferirCartas("1", c.posicoes, "2")
+
+			if !reflect.DeepEqual(transferidas, c.transferidas) {
+				t.Errorf("transferidas %v, esperado %v", transferidas, c.transferidas)
+			}
+			if !reflect.DeepEqual(colecoes["1"], c.restantes) {
+				t.Errorf("restantes %v, esperado %v", colecoes["1"], c.restantes)
+			}
+		})
+	}
+}
+
+func TestAceitarTroca(t *testing.T) {
+	proponente := []Tanque{cartaTeste("A", "1", 1), cartaTeste("B", "1", 2)}
+	destinatario := []Tanque{cartaTeste("C", "2", 3), cartaTeste("D", "2", 4)}
+
+	casos := []struct {
+		nome          string
+		quemAceita    string
+		mesmoGrupo    bool
+		oferecidas    []Tanque
+		pedidas       []Tanque
+		colecao1      []Tanque //Coleção do proponente no momento do aceite
+		final1        []Tanque
+		final2        []Tanque
+		trocaContinua bool
+		erroNaConexao bool
+	}{
+		{
+			nome:       "troca concluída passa as cartas de dono",
+			quemAceita: "2",
+			mesmoGrupo: true,
+			oferecidas: []Tanque{cartaTeste("A", "1", 1)},
+			pedidas:    []Tanque{cartaTeste("D", "2", 4)},
+			colecao1:   proponente,
+			final1:     []Tanque{cartaTeste("B", "1", 2), cartaTeste("D", "1", 4)},
+			final2:     []Tanque{cartaTeste("C", "2", 3), cartaTeste("A", "2", 1)},
+		},
+		{
+			nome:       "presente sem cartas pedidas",
+			quemAceita: "2",
+			mesmoGrupo: true,
+			oferecidas: []Tanque{cartaTeste("A", "1", 1), cartaTeste("B", "1", 2)},
+			colecao1:   proponente,
+			final1:     []Tanque{},
+			final2:     []Tanque{cartaTeste("C", "2", 3), cartaTeste("D", "2", 4), cartaTeste("A", "2", 1), cartaTeste("B", "2", 2)},
+		},
+		{
+			nome:       "carta oferecida que saiu da coleção cancela sem mexer nas coleções",
+			quemAceita: "2",
+			mesmoGrupo: true,
+			oferecidas: []Tanque{cartaTeste("A", "1", 1)},
+			pedidas:    []Tanque{cartaTeste("C", "2", 3)},
+			colecao1:   proponente[1:],
+			final1:     proponente[1:],
+			final2:     destinatario,
+		},
+		{
+			nome:       "jogadores fora do mesmo grupo cancela sem mexer nas coleções",
+			quemAceita: "2",
+			mesmoGrupo: false,
+			oferecidas: []Tanque{cartaTeste("A", "1", 1)},
+			pedidas:    []Tanque{cartaTeste("C", "2", 3)},
+			colecao1:   proponente,
+			final1:     proponente,
+			final2:     destinatario,
+		},
+		{
+			nome:          "proponente não pode aceitar a própria troca",
+			quemAceita:    "1",
+			mesmoGrupo:    true,
+			oferecidas:    []Tanque{cartaTeste("A", "1", 1)},
+			pedidas:       []Tanque{cartaTeste("C", "2", 3)},
+			colecao1:      proponente,
+			final1:        proponente,
+			final2:        destinatario,
+			trocaContinua: true,
+			erroNaConexao: true,
+		},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			colecoes = map[string][]Tanque{
+				"1": append([]Tanque(nil), c.colecao1...),
+				"2": append([]Tanque(nil), destinatario...),
+			}
+			grupos = make(map[string]*Grupo)
+			if c.mesmoGrupo {
+				grupo := &Grupo{Lider: "1", Membros: []string{"1", "2"}}
+				grupos["1"], grupos["2"] = grupo, grupo
+			}
+			trocas = map[int]*Troca{7: {Id: 7, Proponente: "1", Destinatario: "2", Oferecidas: c.oferecidas, Pedidas: c.pedidas}}
+
+			respostas := respostasDe(func(conn net.Conn) {
+				aceitarTroca(conn, c.quemAceita, "7")
+			})
+
+			if !reflect.DeepEqual(colecoes["1"], c.final1) {
+				t.Errorf("coleção do proponente %v, esperado %v", colecoes["1"], c.final1)
+			}
+			if !reflect.DeepEqual(colecoes["2"], c.final2) {
+				t.Errorf("coleção do destinatário %v, esperado %v", colecoes["2"], c.final2)
+			}
+			if _, existe := trocas[7]; existe != c.trocaContinua {
+				t.Errorf("troca pendente %v, esperado %v", existe, c.trocaContinua)
+			}
+			if erro := len(respostas) > 0 && respostas[0].Tipo == "Erro"; erro != c.erroNaConexao {
+				t.Errorf("respostas %v, esperado erro %v", respostas, c.erroNaConexao)
+			}
+
+			//Nenhuma carta é duplicada ou perdida
+			if total := len(colecoes["1"]) + len(colecoes["2"]); total != len(c.colecao1)+len(destinatario) {
+				t.Errorf("total de cartas %d, esperado %d", total, len(c.colecao1)+len(destinatario))
+			}
+		})
+	}
+}
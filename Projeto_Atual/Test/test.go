@@ -15,11 +15,12 @@ import (
 
 // Estruturas de dados no fluxo de dados do cliente e servidor
 type Requisicao struct {
-	Tipo            string `json:"tipo"`
-	Id_remetente    string `json:"id_remetente"`
-	Id_destinatario string `json:"id_destinatario"`
-	Mensagem        string `json:"mensagem"`
-	Carta           Tanque `json:"carta"`
+	Tipo            string   `json:"tipo"`
+	Id_remetente    string   `json:"id_remetente"`
+	Id_destinatario string   `json:"id_destinatario"`
+	Mensagem        string   `json:"mensagem"`
+	Carta           Tanque   `json:"carta"`
+	Cartas          []Tanque `json:"cartas"`
 }
 
 type Resposta struct {
@@ -189,8 +190,42 @@ func cenarioPacks(bot *Bot, resChan <-chan Resposta, errChan <-chan error) bool
 	return true
 }
 
+// Função para abrir pacotes esperando as cartas de cada um, retorna false se o servidor recusar
+func abrirPacotes(bot *Bot, resChan <-chan Resposta, errChan <-chan error, quantidade int) bool {
+	for i := 0; i < quantidade; i++ {
+		enviarRequisicao(bot.conn, Requisicao{Tipo: "Abrir_Pacote", Id_remetente: bot.serverID})
+
+		//Ignora as outras respostas até chegar o resultado do pacote
+		for recebido := false; !recebido; {
+			select {
+			case res := <-resChan:
+				switch res.Tipo {
+				case "Sorteio":
+					recebido = true
+				case "Erro":
+					fmt.Printf("[Bot %d] Pacote recusado: %s\n", bot.id, res.Mensagem)
+					return false
+				}
+
+			case <-time.After(5 * time.Second):
+				fmt.Printf("[Bot %d] Timeout ao abrir pacote.\n", bot.id)
+				return false
+			case err := <-errChan:
+				fmt.Printf("[Bot %d] Conexão perdida ao abrir pacote: %v\n", bot.id, err)
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // Cenário de Batalha: Bots são criados em pares para batalhar
 func cenarioBattle(bot *Bot, resChan <-chan Resposta, errChan <-chan error) bool {
+	//Os dois bots abrem pacotes antes, o servidor recusa batalhas com coleção menor que o deck
+	if !abrirPacotes(bot, resChan, errChan, 2) {
+		return false
+	}
+
 	//Bots com ID par serão os responsáveis por iniciar o pareamento
 	if bot.id%2 == 0 {
 		time.Sleep(1 * time.Second)
@@ -225,15 +260,19 @@ func cenarioBattle(bot *Bot, resChan <-chan Resposta, errChan <-chan error) bool
 				fmt.Printf("[Bot %d] Batalha iniciada!\n", bot.id)
 
 			case "Enviar_Próxima_Carta":
-				indice, _ := strconv.Atoi(res.Mensagem)
-				if indice < len(bot.deck) {
-					carta := bot.deck[indice]
-					enviarRequisicao(bot.conn, Requisicao{Tipo: "Próxima_Carta", Id_remetente: bot.serverID, Carta: carta})
+				//Bot escolhe uma carta aleatória entre as disponíveis no deck
+				if len(res.Cartas) > 0 {
+					indice := rand.Intn(len(res.Cartas))
+					enviarRequisicao(bot.conn, Requisicao{Tipo: "Próxima_Carta", Id_remetente: bot.serverID, Mensagem: strconv.Itoa(indice), Carta: res.Cartas[indice]})
 				}
 
 			case "Fim_Batalha":
 				fmt.Printf("[Bot %d] Batalha finalizada. %s\n", bot.id, res.Mensagem)
 				return true
+
+			case "Erro":
+				fmt.Printf("[Bot %d] Batalha recusada: %s\n", bot.id, res.Mensagem)
+				return false
 			}
 
 		case <-time.After(45 * time.Second): //Timeout para evitar que a batalha prenda o bot para sempre.